@@ -0,0 +1,33 @@
+package deadline
+
+import "time"
+
+// AfterFunc waits until t and then calls f in its own goroutine. It is a
+// replacement of time.AfterFunc() built on top of the Deadline.
+//
+// Returned stop function has the same semantics as time.Timer's Stop(): it
+// prevents f from being called and returns true or returns false if f has
+// been already called.
+func AfterFunc(t time.Time, f func()) (stop func() bool) {
+	return new(Deadline).AfterFunc(t, f)
+}
+
+// AfterFunc sets d to expire at t and calls f in its own goroutine when d
+// expires. Goroutine is started by d's Goer, and time is measured by d's
+// Clock (or Manager), which lets to use AfterFunc() in virtual time.
+//
+// Returned stop function stops d and has the same semantics as
+// AfterFunc()'s one.
+func (d *Deadline) AfterFunc(t time.Time, f func()) (stop func() bool) {
+	unhook := d.AfterExpire(func() {
+		// Deadline is already expired, so its Done() channel must not be
+		// used as the cancelation channel.
+		goer(d.Goer, nil, f)
+	})
+	d.Set(t)
+	return func() bool {
+		ok := d.Stop()
+		unhook()
+		return ok
+	}
+}
@@ -0,0 +1,65 @@
+package deadline
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAfterFunc(t *testing.T) {
+	called := make(chan struct{})
+	stop := AfterFunc(time.Now().Add(time.Millisecond), func() {
+		close(called)
+	})
+	select {
+	case <-called:
+	case <-time.After(time.Second):
+		t.Fatalf("function was not called")
+	}
+	if stop() {
+		t.Errorf("stop() = true after function was called")
+	}
+
+	stop = AfterFunc(time.Now().Add(time.Hour), func() {
+		t.Errorf("stopped function was called")
+	})
+	if !stop() {
+		t.Errorf("stop() = false before function was called")
+	}
+	if stop() {
+		t.Errorf("second stop() = true")
+	}
+}
+
+func TestDeadlineAfterFunc(t *testing.T) {
+	var (
+		c = &stubClock{
+			now: time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC),
+		}
+		started = make(chan struct{}, 1)
+		called  = make(chan struct{})
+	)
+	d := Deadline{
+		Clock: c,
+		Goer: func(_ <-chan struct{}, task func()) {
+			started <- struct{}{}
+			go task()
+		},
+	}
+	d.AfterFunc(c.now.Add(time.Hour), func() {
+		close(called)
+	})
+	if c.after != time.Hour {
+		t.Fatalf("timer is scheduled after %s; want %s", c.after, time.Hour)
+	}
+	c.fn()
+	select {
+	case <-started:
+	default:
+		t.Errorf("function was not started by the Goer")
+	}
+	select {
+	case <-called:
+	case <-time.After(time.Second):
+		t.Fatalf("function was not called")
+	}
+}
@@ -0,0 +1,27 @@
+package deadline
+
+import "sync"
+
+// DoAll runs callbacks in parallel under the deadline d, as Do() does for
+// each of them, and waits for all of them to return or for the deadline to
+// expire. It returns per-callback outcomes: the error returned by the
+// callback or, if the callback was cut off by the expiration, the error
+// returned by Do() (ErrDeadline or the like, see IsTimeout()).
+//
+// It is intended for the fan-out requests to multiple backends under the
+// single budget.
+func (d *Deadline) DoAll(cbs ...func() error) []error {
+	var (
+		errs = make([]error, len(cbs))
+		wg   sync.WaitGroup
+	)
+	wg.Add(len(cbs))
+	for i, cb := range cbs {
+		go func(i int, cb func() error) {
+			defer wg.Done()
+			errs[i] = d.DoErr(cb)
+		}(i, cb)
+	}
+	wg.Wait()
+	return errs
+}
@@ -0,0 +1,54 @@
+package deadline
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDeadlineDoAll(t *testing.T) {
+	var d Deadline
+	d.SetTimeout(20 * time.Millisecond)
+
+	errBackend := errors.New("backend failed")
+	release := make(chan struct{})
+	defer close(release)
+	errs := d.DoAll(
+		func() error { return nil },
+		func() error { return errBackend },
+		func() error {
+			<-release
+			return nil
+		},
+	)
+	if errs[0] != nil {
+		t.Errorf("unexpected error #0: %v", errs[0])
+	}
+	if errs[1] != errBackend {
+		t.Errorf("unexpected error #1: %v; want %v", errs[1], errBackend)
+	}
+	if errs[2] != ErrDeadline {
+		t.Errorf("unexpected error #2: %v; want %v", errs[2], ErrDeadline)
+	}
+}
+
+func TestDeadlineDoAllCutOff(t *testing.T) {
+	var d Deadline
+	d.SetTimeout(5 * time.Millisecond)
+
+	done := make(chan struct{})
+	errs := d.DoAll(func() error {
+		defer close(done)
+		time.Sleep(20 * time.Millisecond)
+		return errors.New("late")
+	})
+	// Let the abandoned callback return while the result is read to make
+	// race detector report any shared state.
+	if err := errs[0]; err != ErrDeadline {
+		t.Errorf("unexpected error: %v; want %v", err, ErrDeadline)
+	}
+	<-done
+	if err := errs[0]; err != ErrDeadline {
+		t.Errorf("result was overwritten by the abandoned callback: %v", err)
+	}
+}
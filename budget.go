@@ -0,0 +1,82 @@
+package deadline
+
+import "time"
+
+// ForDownstream returns new Deadline armed the margin before d's deadline.
+// It is intended for outgoing calls made while serving a request: margin
+// reserves time to process and serialize the response after the downstream
+// call returns. If d is not set, returned Deadline is not set too.
+//
+// Returned Deadline inherits d's Goer, RejectGoer, Manager and Clock. Note
+// that it does not follow further changes of d. Use NewChild() to get a
+// Deadline which does.
+func (d *Deadline) ForDownstream(margin time.Duration) *Deadline {
+	c := &Deadline{
+		Goer:       d.Goer,
+		RejectGoer: d.RejectGoer,
+		Manager:    d.Manager,
+		Clock:      d.Clock,
+	}
+	if at := d.Expires(); !at.IsZero() {
+		c.Set(at.Add(-margin))
+	}
+	return c
+}
+
+// Reserve carves a reserved tail of the given duration out of d's budget. It
+// returns Deadline armed dur before d's deadline, which is intended for the
+// inner operations, while the reserved tail is left for the mandatory
+// finalization work such as audit log write or response flush.
+//
+// Calling release gives the reserved time back: returned Deadline is re-armed
+// to d's deadline.
+func (d *Deadline) Reserve(dur time.Duration) (inner *Deadline, release func()) {
+	inner = d.ForDownstream(dur)
+	return inner, func() {
+		inner.Set(d.Expires())
+	}
+}
+
+// Fraction returns child Deadline (see NewChild()) armed to expire after the
+// p fraction of d's remaining time. If d is not set, returned Deadline is not
+// set too. If d is already expired, returned Deadline is expired as well.
+//
+// For example, Fraction(0.3) gives 30% of the remaining budget to the
+// operation, leaving the rest for the subsequent ones. Returned Deadline
+// should be stopped (see Stop()) if the operation completes before it
+// expires.
+func (d *Deadline) Fraction(p float64) *Deadline {
+	c := NewChild(d)
+	if n := d.Remaining(); n > 0 {
+		c.SetTimeout(time.Duration(float64(n) * p))
+	}
+	return c
+}
+
+// Split divides d's remaining time into n equal consecutive phases and
+// returns child Deadlines (see NewChild()) for each of them. That is, i-th
+// returned Deadline expires at the end of the i-th phase and the last one
+// expires with d. If d is not set, returned Deadlines are not set too.
+//
+// It is intended for multi-step operations (such as connect, handshake and
+// request) which must not let a slow step to consume the budget of the
+// subsequent ones. Deadlines of the completed phases should be stopped (see
+// Stop()) to unlink them from d.
+func (d *Deadline) Split(n int) []*Deadline {
+	if n <= 0 {
+		panic("deadline: non-positive number of phases")
+	}
+	var (
+		ret  = make([]*Deadline, n)
+		now  = d.now()
+		rem  = d.Remaining()
+		step = rem / time.Duration(n)
+	)
+	for i := range ret {
+		ret[i] = NewChild(d)
+		if rem > 0 && i < n-1 {
+			ret[i].Set(now.Add(step * time.Duration(i+1)))
+		}
+	}
+	return ret
+}
@@ -0,0 +1,60 @@
+package deadline
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDeadlineForDownstream(t *testing.T) {
+	var d Deadline
+	if c := d.ForDownstream(time.Second); !c.Expires().IsZero() {
+		t.Errorf("downstream deadline of not set deadline is set")
+	}
+	at := time.Now().Add(time.Hour)
+	d.Set(at)
+	c := d.ForDownstream(time.Second)
+	if act, exp := c.Expires(), at.Add(-time.Second); !act.Equal(exp) {
+		t.Errorf("unexpected downstream deadline: %s; want %s", act, exp)
+	}
+}
+
+func TestDeadlineReserve(t *testing.T) {
+	var d Deadline
+	at := time.Now().Add(time.Hour)
+	d.Set(at)
+	inner, release := d.Reserve(time.Minute)
+	if act, exp := inner.Expires(), at.Add(-time.Minute); !act.Equal(exp) {
+		t.Errorf("unexpected inner deadline: %s; want %s", act, exp)
+	}
+	release()
+	if act := inner.Expires(); !act.Equal(at) {
+		t.Errorf("unexpected inner deadline after release: %s; want %s", act, at)
+	}
+}
+
+func TestDeadlineFraction(t *testing.T) {
+	var d Deadline
+	if c := d.Fraction(0.5); !c.Expires().IsZero() {
+		t.Errorf("fraction of not set deadline is set")
+	}
+	d.SetTimeout(time.Hour)
+	c := d.Fraction(0.5)
+	if n := c.Remaining(); n > 30*time.Minute || n < 29*time.Minute {
+		t.Errorf("unexpected fraction remaining time: %s", n)
+	}
+}
+
+func TestDeadlineSplit(t *testing.T) {
+	var d Deadline
+	d.SetTimeout(3 * time.Hour)
+	ps := d.Split(3)
+	for i, p := range ps {
+		exp := time.Duration(i+1) * time.Hour
+		if n := p.Remaining(); n > exp || n < exp-time.Minute {
+			t.Errorf("phase #%d remaining time is %s; want %s", i, n, exp)
+		}
+	}
+	if !ps[2].Expires().Equal(d.Expires()) {
+		t.Errorf("last phase does not expire with deadline")
+	}
+}
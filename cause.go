@@ -0,0 +1,55 @@
+package deadline
+
+import (
+	"context"
+	"errors"
+)
+
+// ContextCause converts deadline error err (such as ErrDeadline or
+// *ExpireError) into an error suitable to be a cause of the context
+// cancellation, e.g. for the context.CancelCauseFunc. Returned error matches
+// both err and context.DeadlineExceeded in terms of errors.Is(), thus the
+// reason of timeout survives crossing the Deadline/context boundary.
+//
+// If err is not a timeout error (see IsTimeout()), it is returned as is.
+func ContextCause(err error) error {
+	if !IsTimeout(err) || errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+	return &causeError{
+		cause:  err,
+		target: context.DeadlineExceeded,
+	}
+}
+
+// ContextError returns an error describing why the ctx is done. It returns
+// nil if ctx is not done yet. If ctx deadline is exceeded, returned error
+// matches ErrDeadline in terms of errors.Is() and unwraps to the
+// context.Cause(ctx). Otherwise context.Cause(ctx) is returned.
+func ContextError(ctx context.Context) error {
+	err := ctx.Err()
+	if err == nil {
+		return nil
+	}
+	cause := context.Cause(ctx)
+	if err != context.DeadlineExceeded || errors.Is(cause, ErrDeadline) {
+		return cause
+	}
+	return &causeError{
+		cause:  cause,
+		target: ErrDeadline,
+	}
+}
+
+// causeError is an error which wraps the cause and additionally matches the
+// target error.
+type causeError struct {
+	cause  error
+	target error
+}
+
+func (e *causeError) Error() string        { return e.cause.Error() }
+func (e *causeError) Unwrap() error        { return e.cause }
+func (e *causeError) Is(target error) bool { return target == e.target }
+func (e *causeError) Timeout() bool        { return true }
+func (e *causeError) Temporary() bool      { return true }
@@ -0,0 +1,54 @@
+package deadline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestContextCause(t *testing.T) {
+	var d Deadline
+	d.Set(time.Now().Add(time.Millisecond))
+	errPayment := errors.New("payment authorization timed out")
+	err := d.Do(func() {
+		time.Sleep(10 * time.Millisecond)
+	}, WithError(fmt.Errorf("%w: %w", ErrDeadline, errPayment)))
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	cancel(ContextCause(err))
+	if !errors.Is(context.Cause(ctx), context.DeadlineExceeded) {
+		t.Errorf("cause does not match context.DeadlineExceeded")
+	}
+	if !errors.Is(context.Cause(ctx), errPayment) {
+		t.Errorf("cause does not match original error")
+	}
+}
+
+func TestContextError(t *testing.T) {
+	errSLA := errors.New("sla exceeded")
+	ctx, cancel := context.WithDeadlineCause(
+		context.Background(), time.Now().Add(-time.Second), errSLA,
+	)
+	defer cancel()
+	err := ContextError(ctx)
+	if !errors.Is(err, ErrDeadline) {
+		t.Errorf("error does not match ErrDeadline")
+	}
+	if !errors.Is(err, errSLA) {
+		t.Errorf("error does not match context cause")
+	}
+	if !IsTimeout(err) {
+		t.Errorf("error is not a timeout")
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	if err := ContextError(ctx); err != nil {
+		t.Errorf("unexpected error of not done context: %v", err)
+	}
+	cancel()
+	if err := ContextError(ctx); err != context.Canceled {
+		t.Errorf("unexpected error of canceled context: %v", err)
+	}
+}
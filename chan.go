@@ -0,0 +1,37 @@
+package deadline
+
+// Recv receives a value from ch unless the deadline d expires first. It
+// returns ErrClosed if ch is closed, or the expiration reason (ErrDeadline or
+// the like) if d is expired. Note that receive from nil channel blocks until
+// the expiration, thus it blocks forever if d is not set.
+func Recv[T any](d *Deadline, ch <-chan T) (T, error) {
+	var zero T
+	if isExpired(d) {
+		return zero, d.expired()
+	}
+	select {
+	case v, ok := <-ch:
+		if !ok {
+			return zero, ErrClosed
+		}
+		return v, nil
+	case <-d.Done():
+		return zero, d.expired()
+	}
+}
+
+// Send sends v to ch unless the deadline d expires first. It returns the
+// expiration reason (ErrDeadline or the like) if d is expired. As with the
+// plain send statement, Send() panics if ch is closed, and send to nil
+// channel blocks until the expiration.
+func Send[T any](d *Deadline, ch chan<- T, v T) error {
+	if isExpired(d) {
+		return d.expired()
+	}
+	select {
+	case ch <- v:
+		return nil
+	case <-d.Done():
+		return d.expired()
+	}
+}
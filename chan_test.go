@@ -0,0 +1,32 @@
+package deadline
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRecvSend(t *testing.T) {
+	var d Deadline
+	d.SetTimeout(time.Second)
+
+	ch := make(chan int, 1)
+	if err := Send(&d, ch, 42); err != nil {
+		t.Fatalf("Send() error: %v", err)
+	}
+	if v, err := Recv(&d, ch); err != nil || v != 42 {
+		t.Fatalf("Recv() = %v, %v; want 42", v, err)
+	}
+	close(ch)
+	if _, err := Recv(&d, ch); err != ErrClosed {
+		t.Fatalf("unexpected error: %v; want %v", err, ErrClosed)
+	}
+
+	d.SetTimeout(10 * time.Millisecond)
+	var nilCh chan int
+	if _, err := Recv(&d, nilCh); err != ErrDeadline {
+		t.Fatalf("unexpected error: %v; want %v", err, ErrDeadline)
+	}
+	if err := Send(&d, make(chan int), 1); err != ErrDeadline {
+		t.Fatalf("unexpected error: %v; want %v", err, ErrDeadline)
+	}
+}
@@ -0,0 +1,81 @@
+package deadline
+
+import (
+	"sync"
+	"time"
+)
+
+// NewChild returns new Deadline which expires when either its own deadline
+// or the parent's one is reached. Set() of the child cannot exceed the
+// parent: deadline point after the parent's one (or zero time, if parent is
+// set) is replaced by the parent's deadline point. Initially the child is
+// set to the parent's deadline.
+//
+// When parent is canceled (see Cancel()), the child is canceled with the same
+// reason. Child inherits the parent's Goer, RejectGoer, Manager, Clock and
+// Monotonic.
+//
+// Child is linked to the parent until one of them expires or the child is
+// stopped, cleared or released; Set() of the child links it again. Note that
+// child which is abandoned while linked stays referenced by the parent until
+// the parent expires.
+func NewChild(parent *Deadline) *Deadline {
+	d := &Deadline{
+		Goer:       parent.Goer,
+		RejectGoer: parent.RejectGoer,
+		Manager:    parent.Manager,
+		Clock:      parent.Clock,
+		Monotonic:  parent.Monotonic,
+		link:       &link{parent: parent},
+	}
+	d.OnExpire(d.link.unlink)
+	d.Set(time.Time{})
+	return d
+}
+
+// link binds child deadline to its parent.
+type link struct {
+	mu     sync.Mutex
+	parent *Deadline
+	stop   func() bool // Stops the parent's hook; nil if not linked.
+}
+
+// bind links child d to the parent if it is not linked yet and returns t
+// clamped to the parent's deadline point.
+func (l *link) bind(d *Deadline, t time.Time) time.Time {
+	l.mu.Lock()
+	if l.stop == nil {
+		l.stop = l.parent.AfterExpire(func() {
+			l.mu.Lock()
+			l.stop = nil
+			l.mu.Unlock()
+			d.expireWithCause(l.cause())
+		})
+	}
+	l.mu.Unlock()
+
+	at := l.parent.Expires()
+	if !at.IsZero() && (t.IsZero() || t.After(at)) {
+		return at
+	}
+	return t
+}
+
+// cause returns the reason of the parent's expiration to be used by the
+// child.
+func (l *link) cause() error {
+	err := l.parent.Err()
+	if err == nil || err == ErrDeadline {
+		return nil
+	}
+	return err
+}
+
+func (l *link) unlink() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.stop != nil {
+		l.stop()
+		l.stop = nil
+	}
+}
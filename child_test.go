@@ -0,0 +1,83 @@
+package deadline
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNewChild(t *testing.T) {
+	var parent Deadline
+	at := time.Now().Add(time.Hour)
+	parent.Set(at)
+
+	child := NewChild(&parent)
+	if act := child.Expires(); !act.Equal(at) {
+		t.Fatalf("child expires at %s; want parent's %s", act, at)
+	}
+	child.Set(at.Add(time.Hour))
+	if act := child.Expires(); !act.Equal(at) {
+		t.Fatalf("child deadline exceeds parent's one: %s", act)
+	}
+	child.Set(time.Now().Add(10 * time.Millisecond))
+	select {
+	case <-child.Done():
+	case <-time.After(time.Second):
+		t.Fatalf("child deadline was not reached")
+	}
+	select {
+	case <-parent.Done():
+		t.Fatalf("parent expired with child")
+	default:
+	}
+
+	child.Set(time.Now().Add(time.Hour))
+	errStop := errors.New("stop")
+	parent.Cancel(errStop)
+	select {
+	case <-child.Done():
+	case <-time.After(time.Second):
+		t.Fatalf("child was not expired with parent")
+	}
+	if err := child.Err(); err != errStop {
+		t.Errorf("unexpected child error: %v; want %v", err, errStop)
+	}
+}
+
+func TestNewChildUnlink(t *testing.T) {
+	var parent Deadline
+	parent.Set(time.Now().Add(time.Hour))
+	for i := 0; i < 100; i++ {
+		c := NewChild(&parent)
+		c.SetTimeout(time.Minute)
+		switch i % 3 {
+		case 0:
+			c.Stop()
+		case 1:
+			c.Clear(false)
+		case 2:
+			c.ClearAndWait()
+		}
+	}
+	parent.mu.Lock()
+	n := len(parent.once)
+	parent.mu.Unlock()
+	if n != 0 {
+		t.Errorf("parent retains %d hooks of disarmed children", n)
+	}
+}
+
+func TestNewChildSetAll(t *testing.T) {
+	var (
+		m      Manager
+		parent = Deadline{Manager: &m}
+	)
+	at := time.Now().Add(time.Hour)
+	parent.Set(at)
+	child := NewChild(&parent)
+
+	m.SetAll([]*Deadline{child}, at.Add(time.Hour))
+	if act := child.Expires(); !act.Equal(at) {
+		t.Errorf("child deadline exceeds parent's one: %s; want %s", act, at)
+	}
+}
@@ -0,0 +1,90 @@
+package deadline
+
+import "time"
+
+// Clock is a source of time used by the Deadline. It allows to run deadlines
+// in virtual time, for example, in tests or simulations.
+type Clock interface {
+	// Now returns current time.
+	Now() time.Time
+
+	// AfterFunc calls f in its own goroutine after duration elapses, much
+	// like time.AfterFunc() does.
+	AfterFunc(d time.Duration, f func()) Timer
+
+	// NewTimer returns Timer which sends current time on its channel after
+	// duration elapses, much like time.NewTimer() does.
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer is a timer created by the Clock.
+type Timer interface {
+	// Chan returns the channel on which the time is delivered. It returns
+	// nil for timers created by Clock's AfterFunc().
+	Chan() <-chan time.Time
+
+	// Stop prevents the timer from firing, as time.Timer's Stop() does.
+	Stop() bool
+
+	// Reset changes the timer to expire after duration, as time.Timer's
+	// Reset() does.
+	Reset(d time.Duration) bool
+}
+
+// RealClock is the Clock backed by the time package.
+var RealClock Clock = realClock{}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) AfterFunc(d time.Duration, f func()) Timer {
+	return (*realTimer)(time.AfterFunc(d, f))
+}
+
+func (realClock) NewTimer(d time.Duration) Timer {
+	return (*realTimer)(time.NewTimer(d))
+}
+
+type realTimer time.Timer
+
+func (t *realTimer) Chan() <-chan time.Time     { return t.C }
+func (t *realTimer) Stop() bool                 { return t.timer().Stop() }
+func (t *realTimer) Reset(d time.Duration) bool { return t.timer().Reset(d) }
+
+func (t *realTimer) timer() *time.Timer {
+	return (*time.Timer)(t)
+}
+
+// clock returns Clock used by d. Managed deadline without its own Clock uses
+// the Manager's one.
+func (d *Deadline) clock() Clock {
+	if d.Clock != nil {
+		return d.Clock
+	}
+	if d.Manager != nil {
+		return d.Manager.clock()
+	}
+	return RealClock
+}
+
+// now returns current time of the d's Clock.
+func (d *Deadline) now() time.Time {
+	if d.Clock != nil {
+		return d.Clock.Now()
+	}
+	if d.Manager != nil {
+		return d.Manager.now()
+	}
+	return time.Now()
+}
+
+// checkClock panics if d is scheduled by the Manager which uses different
+// Clock.
+func (d *Deadline) checkClock() {
+	if d.Clock != nil && d.Manager != nil && d.Clock != d.Manager.clock() {
+		panic("deadline: Clock of managed Deadline differs from the Manager's one")
+	}
+}
@@ -0,0 +1,67 @@
+package deadline
+
+import (
+	"testing"
+	"time"
+)
+
+type stubClock struct {
+	now   time.Time
+	after time.Duration
+	fn    func()
+}
+
+func (c *stubClock) Now() time.Time { return c.now }
+
+func (c *stubClock) AfterFunc(d time.Duration, f func()) Timer {
+	c.after, c.fn = d, f
+	return stubTimer{}
+}
+
+func (c *stubClock) NewTimer(d time.Duration) Timer {
+	return stubTimer{}
+}
+
+type stubTimer struct{}
+
+func (stubTimer) Chan() <-chan time.Time     { return nil }
+func (stubTimer) Stop() bool                 { return true }
+func (stubTimer) Reset(d time.Duration) bool { return true }
+
+func TestDeadlineClock(t *testing.T) {
+	c := &stubClock{
+		now: time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	d := Deadline{Clock: c}
+	d.SetTimeout(time.Hour)
+	if c.after != time.Hour {
+		t.Fatalf("timer is scheduled after %s; want %s", c.after, time.Hour)
+	}
+	if act, exp := d.Expires(), c.now.Add(time.Hour); !act.Equal(exp) {
+		t.Fatalf("Expires() = %s; want %s", act, exp)
+	}
+	select {
+	case <-d.Done():
+		t.Fatalf("deadline expired before clock fired")
+	default:
+	}
+	c.fn()
+	select {
+	case <-d.Done():
+	default:
+		t.Fatalf("deadline is not expired after clock fired")
+	}
+}
+
+func TestDeadlineClockManager(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("no panic on Clock mismatch")
+		}
+	}()
+	d := Deadline{
+		Clock:   new(stubClock),
+		Manager: new(Manager),
+	}
+	d.SetTimeout(time.Hour)
+}
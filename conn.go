@@ -0,0 +1,95 @@
+package deadline
+
+import (
+	"net"
+	"sync/atomic"
+	"time"
+)
+
+// aLongTimeAgo is a non-zero time, far in the past, used for immediate
+// interruption of the network I/O.
+var aLongTimeAgo = time.Unix(1, 0)
+
+// DoConn runs callback with given conn in a separate goroutine under the
+// deadline d. If the deadline expires before callback returns, DoConn
+// interrupts blocked conn I/O by setting conn's deadline in the past, waits
+// for callback to return and returns ErrDeadline. Otherwise it returns an
+// error returned by the callback.
+//
+// Note that conn deadline is not restored after interruption.
+func DoConn(d *Deadline, conn net.Conn, cb func(net.Conn) error) error {
+	return DoInterrupt(d,
+		func() error {
+			return conn.SetDeadline(aLongTimeAgo)
+		},
+		func() error {
+			return cb(conn)
+		},
+	)
+}
+
+// DoInterrupt runs callback in a separate goroutine under the deadline d. If
+// the deadline expires before callback returns, DoInterrupt calls interrupt
+// exactly once, waits for callback to return and returns the expiration
+// reason as d's Err() does (ErrDeadline unless d was canceled). If interrupt
+// fails, it returns *InterruptError without waiting for the callback.
+// Otherwise it returns an error returned by the callback.
+//
+// It is intended to be used with resources which have no deadline support;
+// interrupt is usually some Close() method which makes the blocked callback
+// to return.
+func DoInterrupt(d *Deadline, interrupt func() error, cb func() error) error {
+	const (
+		pending = iota
+		running
+		canceled
+	)
+	var (
+		done  = d.Done()
+		ok    = make(chan struct{})
+		state int32
+		err   error
+	)
+	goer(d.Goer, done, func() {
+		if !atomic.CompareAndSwapInt32(&state, pending, running) {
+			return
+		}
+		defer close(ok)
+		err = cb()
+	})
+	select {
+	case <-ok:
+		return err
+	case <-done:
+	}
+	if atomic.CompareAndSwapInt32(&state, pending, canceled) {
+		// Callback was not even started.
+		return d.expired()
+	}
+	interrupted := make(chan error, 1)
+	go func() {
+		interrupted <- interrupt()
+	}()
+	select {
+	case <-ok:
+		// Callback returned by itself or interrupt made it return while
+		// interrupt is still in progress.
+		select {
+		case ierr := <-interrupted:
+			if ierr != nil {
+				return &InterruptError{
+					Err: ierr,
+				}
+			}
+		default:
+		}
+	case ierr := <-interrupted:
+		if ierr != nil {
+			return &InterruptError{
+				Err: ierr,
+			}
+		}
+		<-ok
+	}
+	return d.expired()
+}
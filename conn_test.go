@@ -0,0 +1,88 @@
+package deadline
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestDoConn(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	var d Deadline
+	d.Set(time.Now().Add(10 * time.Millisecond))
+
+	var readErr error
+	err := DoConn(&d, server, func(conn net.Conn) error {
+		_, readErr = conn.Read(make([]byte, 1))
+		return readErr
+	})
+	if err != ErrDeadline {
+		t.Errorf("unexpected error: %v; want %v", err, ErrDeadline)
+	}
+	if ne, ok := readErr.(net.Error); !ok || !ne.Timeout() {
+		t.Errorf("unexpected read error: %v; want timeout", readErr)
+	}
+}
+
+func TestDoInterrupt(t *testing.T) {
+	var d Deadline
+	d.Set(time.Now().Add(10 * time.Millisecond))
+
+	var (
+		release = make(chan struct{})
+		calls   int
+		errBusy = errors.New("busy")
+	)
+	defer close(release)
+	err := DoInterrupt(&d,
+		func() error {
+			calls++
+			return errBusy
+		},
+		func() error {
+			// Callback is not interrupted by the failed interrupt.
+			<-release
+			return nil
+		},
+	)
+	e, ok := err.(*InterruptError)
+	if !ok || e.Err != errBusy {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("interrupt called %d times; want 1", calls)
+	}
+}
+
+func TestDoInterruptCanceled(t *testing.T) {
+	var d Deadline
+	d.Set(time.Now().Add(time.Hour))
+	errStop := errors.New("stop")
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		d.Cancel(errStop)
+	}()
+	var (
+		closed = make(chan struct{})
+		block  = make(chan struct{})
+	)
+	err := DoInterrupt(&d,
+		func() error {
+			close(closed)
+			<-block // Interrupt which never returns.
+			return nil
+		},
+		func() error {
+			<-closed
+			return nil
+		},
+	)
+	close(block)
+	if err != errStop {
+		t.Errorf("unexpected error: %v; want %v", err, errStop)
+	}
+}
@@ -0,0 +1,121 @@
+package deadline
+
+import (
+	"context"
+	"time"
+)
+
+// Context returns context.Context which is done when d expires. Returned
+// context follows subsequent Set() calls made before the expiration: moving
+// the deadline point moves the moment when context becomes done. Once done,
+// context stays done even if d is re-armed later; call Context() again to get
+// a context for the new deadline.
+//
+// Context's Err() returns context.DeadlineExceeded after d expiration, or
+// context.Canceled if d was canceled (see IsCanceled()).
+func (d *Deadline) Context() context.Context {
+	return &deadlineContext{
+		d:    d,
+		done: d.doneChan(),
+	}
+}
+
+type deadlineContext struct {
+	d    *Deadline
+	done chan struct{}
+}
+
+func (c *deadlineContext) Deadline() (time.Time, bool) {
+	t := c.d.Expires()
+	return t, !t.IsZero()
+}
+
+func (c *deadlineContext) Done() <-chan struct{} {
+	return c.done
+}
+
+func (c *deadlineContext) Err() error {
+	select {
+	case <-c.done:
+	default:
+		return nil
+	}
+	if IsCanceled(c.d.Err()) {
+		return context.Canceled
+	}
+	return context.DeadlineExceeded
+}
+
+func (c *deadlineContext) Value(key interface{}) interface{} {
+	return nil
+}
+
+func (c *deadlineContext) String() string {
+	return "deadline.Context"
+}
+
+// FromContext returns Deadline which is set to the ctx deadline (if any) and
+// which also expires when ctx is done for any reason. Do() calls interrupted
+// by ctx cancellation return error described by ContextError().
+func FromContext(ctx context.Context) *Deadline {
+	d := new(Deadline)
+	if t, ok := ctx.Deadline(); ok {
+		d.Set(t)
+	}
+	d.Follow(ctx)
+	return d
+}
+
+// Follow makes d to also expire when ctx is done, unless d is already
+// expired by then. Do() calls interrupted by ctx cancellation return error
+// described by ContextError(). Unlike FromContext(), it binds an existing
+// Deadline, which may be set and re-armed independently of ctx.
+//
+// Returned stop function unbinds d from ctx. It has the same semantics as
+// the one returned by context.AfterFunc().
+func (d *Deadline) Follow(ctx context.Context) (stop func() bool) {
+	return context.AfterFunc(ctx, func() {
+		d.expireContext(ctx)
+	})
+}
+
+func (d *Deadline) expireContext(ctx context.Context) {
+	d.mu.Lock()
+	if d.done != nil {
+		select {
+		case <-d.done:
+			// Already expired by its own timer.
+			d.mu.Unlock()
+			return
+		default:
+		}
+	}
+	expired := d.set(d.now())
+	d.cause = ContextError(ctx)
+	merges := d.merges
+	d.mu.Unlock()
+	if expired {
+		d.runHooks()
+	}
+	notify(merges)
+}
+
+// DoContext is like Do() but passes a context to the callback which is done
+// when the deadline expires. That is, callback may observe the expiration and
+// stop its work instead of being abandoned.
+//
+// If WithDeadline() option is given, the context is also done when the call
+// deadline is reached.
+func (d *Deadline) DoContext(cb func(context.Context), opts ...Option) error {
+	var o callOptions
+	o.apply(opts)
+	ctx := d.Context()
+	if !o.deadline.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, o.deadline)
+		defer cancel()
+	}
+	return d.Do(func() {
+		cb(ctx)
+	}, opts...)
+}
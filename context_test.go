@@ -0,0 +1,122 @@
+package deadline
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestDeadlineContext(t *testing.T) {
+	var d Deadline
+	d.Set(time.Now().Add(20 * time.Millisecond))
+	ctx := d.Context()
+	if _, ok := ctx.Deadline(); !ok {
+		t.Fatalf("context has no deadline")
+	}
+
+	// Move the deadline further; context must follow it.
+	d.Set(time.Now().Add(100 * time.Millisecond))
+	select {
+	case <-ctx.Done():
+		t.Fatalf("context is done before re-armed deadline")
+	case <-time.After(50 * time.Millisecond):
+	}
+	if err := ctx.Err(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatalf("context is not done after deadline")
+	}
+	if err := ctx.Err(); err != context.DeadlineExceeded {
+		t.Fatalf("unexpected error: %v; want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	d := FromContext(ctx)
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	err := d.Do(func() {
+		time.Sleep(time.Second)
+	})
+	if err != context.Canceled {
+		t.Fatalf("unexpected error: %v; want %v", err, context.Canceled)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	d = FromContext(ctx)
+	if !d.Expires().Equal(mustDeadline(t, ctx)) {
+		t.Fatalf("deadline is not inherited from context")
+	}
+	err = d.Do(func() {
+		time.Sleep(time.Second)
+	})
+	if !IsTimeout(err) {
+		t.Fatalf("unexpected error: %v; want timeout", err)
+	}
+}
+
+func TestDeadlineFollow(t *testing.T) {
+	var d Deadline
+	d.Set(time.Now().Add(time.Hour))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stop := d.Follow(ctx)
+	if !stop() {
+		t.Fatalf("stop() = false before ctx is done")
+	}
+	cancel()
+	time.Sleep(10 * time.Millisecond)
+	if err := d.Err(); err != nil {
+		t.Fatalf("deadline expired after unbinding: %v", err)
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	d.Follow(ctx)
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	err := d.Do(func() {
+		time.Sleep(time.Second)
+	})
+	if err != context.Canceled {
+		t.Fatalf("unexpected error: %v; want %v", err, context.Canceled)
+	}
+}
+
+func mustDeadline(t *testing.T, ctx context.Context) time.Time {
+	dl, ok := ctx.Deadline()
+	if !ok {
+		t.Fatalf("context has no deadline")
+	}
+	return dl
+}
+
+func TestDeadlineDoContext(t *testing.T) {
+	var d Deadline
+	d.Set(time.Now().Add(10 * time.Millisecond))
+	stopped := make(chan error, 1)
+	err := d.DoContext(func(ctx context.Context) {
+		<-ctx.Done()
+		stopped <- ctx.Err()
+	})
+	if err != ErrDeadline {
+		t.Fatalf("unexpected error: %v; want %v", err, ErrDeadline)
+	}
+	select {
+	case err := <-stopped:
+		if err != context.DeadlineExceeded {
+			t.Fatalf("unexpected context error: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("callback did not observe expiration")
+	}
+}
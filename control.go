@@ -0,0 +1,71 @@
+package deadline
+
+import "time"
+
+// Control is given to the callback run by DoControl(). It lets long
+// operations which are making progress to push the deadline forward.
+type Control struct {
+	d        *Deadline
+	done     <-chan struct{}
+	interval time.Duration // Used by Touch().
+}
+
+// Done returns a channel which is closed when the deadline expires.
+func (c *Control) Done() <-chan struct{} {
+	return c.done
+}
+
+// Extend moves the deadline point forward to be at least dur after now. It
+// never moves the deadline point backward. It returns false if the deadline
+// is already expired, since then Do() could have returned and extending the
+// deadline would affect unrelated calls. Extend does nothing and returns true
+// if the deadline is not set.
+func (c *Control) Extend(dur time.Duration) bool {
+	return c.d.extendTo(c.d.now().Add(dur))
+}
+
+// Touch reports progress of the callback. It is the same as Extend() with
+// the timeout which was remaining when DoControl() was called.
+func (c *Control) Touch() bool {
+	return c.Extend(c.interval)
+}
+
+// DoControl is like Do() but passes Control to the callback, which allows
+// the callback to extend the deadline while it is making progress.
+func (d *Deadline) DoControl(cb func(*Control), opts ...Option) error {
+	c := &Control{
+		d:        d,
+		done:     d.Done(),
+		interval: d.Remaining(),
+	}
+	return d.Do(func() {
+		cb(c)
+	}, opts...)
+}
+
+// extendTo moves the deadline point forward to t unless d is expired. It
+// returns false if d is expired.
+func (d *Deadline) extendTo(t time.Time) bool {
+	d.mu.Lock()
+	d.sync()
+	if d.at.IsZero() {
+		d.mu.Unlock()
+		return true
+	}
+	select {
+	case <-d.done:
+		d.mu.Unlock()
+		return false
+	default:
+	}
+	if !t.After(d.at) {
+		d.mu.Unlock()
+		return true
+	}
+	d.set(t)
+	at, merges := d.at, d.merges
+	d.mu.Unlock()
+	d.emit(Event{Type: EventSet, At: at})
+	notify(merges)
+	return true
+}
@@ -0,0 +1,32 @@
+package deadline
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDeadlineDoControl(t *testing.T) {
+	var d Deadline
+	d.SetTimeout(20 * time.Millisecond)
+	err := d.DoControl(func(c *Control) {
+		for i := 0; i < 5; i++ {
+			time.Sleep(10 * time.Millisecond)
+			if !c.Touch() {
+				t.Errorf("Touch() = false before expiration")
+			}
+		}
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = d.DoControl(func(c *Control) {
+		<-c.Done()
+		if c.Extend(time.Hour) {
+			t.Errorf("Extend() = true after expiration")
+		}
+	})
+	if err != ErrDeadline {
+		t.Fatalf("unexpected error: %v; want %v", err, ErrDeadline)
+	}
+}
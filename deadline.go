@@ -1,19 +1,45 @@
 package deadline
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"runtime/debug"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 var ErrDeadline = deadlineError{}
 
+// ErrCanceled is returned by Do() when the deadline is canceled by Cancel()
+// without explicit reason.
+var ErrCanceled = errors.New("deadline canceled")
+
+// ErrRejected is returned by Do() when the RejectGoer (or the one set by
+// WithRejectGoer()) reports that it dropped the callback, for example, due to the overflow of
+// the WorkerPool's queue. It matches ErrDeadline in terms of errors.Is(), but
+// can be distinguished from it to detect saturation.
+var ErrRejected = rejectedError{}
+
+// ErrCleared is returned by Do() interrupted by the Clear(true) call.
+var ErrCleared = errors.New("deadline cleared")
+
+// ErrClosed is returned by Recv() when the channel is closed.
+var ErrClosed = errors.New("deadline: channel closed")
+
 // Do is a helper method that runs callback in a separate goroutine with given
 // deadline. If deadline expires earlier than callback returns, it returns
 // ErrDeadline. In other cases returned error is nil.
-func Do(deadline time.Time, cb func()) error {
+//
+// Given options are applied as for the Deadline.Do() call, for example, to
+// set up Goer, panic recovery or the error returned on expiration.
+func Do(deadline time.Time, cb func(), opts ...Option) error {
 	d := Deadline{}
 	d.Set(deadline)
-	return d.Do(cb)
+	return d.Do(cb, opts...)
 }
 
 // Deadline contains deadline handling logic. It is intended to be much like
@@ -25,82 +51,794 @@ type Deadline struct {
 	// uses some goroutine pool.
 	Goer GoFunc
 
-	mu    sync.Mutex
-	done  chan struct{}
-	timer *time.Timer
+	// RejectGoer is an optional goroutine starter which is able to report
+	// that it dropped the callback (see WithRejectGoer()). It is used
+	// instead of Goer when non-nil.
+	RejectGoer RejectGoFunc
+
+	// Alloc is an optional allocator of Done() channels. If nil, new channel
+	// is made every time the deadline needs it.
+	Alloc Allocator
+
+	// Clock is an optional source of time. If nil, Manager's Clock or
+	// RealClock is used. Note that deadline with non-nil Clock is not
+	// scheduled by the package level scheduler. Managed deadline must use the
+	// same Clock as its Manager does (see Manager's Clock).
+	Clock Clock
+
+	// Monotonic makes deadline points given to Set() to be converted into
+	// offsets from the current monotonic clock reading. That is, once the
+	// deadline is set, its expiration and Remaining() are not affected by
+	// the wall clock jumps, even if given time has no monotonic reading
+	// (e.g. if it was parsed or received over the wire).
+	Monotonic bool
+
+	// Soft is the margin before the deadline point which defines the soft
+	// deadline reported by Warn() and OnWarn().
+	Soft time.Duration
+
+	// Name is an optional name of the deadline used for diagnostics. It is
+	// reported by errors returned from Do() (see *ExpireError's Label), used
+	// as operation name by the LatencyRecorder and printed by Manager's
+	// Dump().
+	Name string
+
+	// Tags are optional tags of the deadline. They allow to expire all live
+	// deadlines with some tag by Manager's ExpireTagged().
+	Tags []string
+
+	// Manager is an optional Manager the deadline reports its state to and
+	// which schedules its expiration instead of the per-Deadline timer. It
+	// must not be changed after first use of the Deadline.
+	Manager *Manager
+
+	// Latency is an optional LatencyRecorder which is fed by Do() with the
+	// callback latencies of the operation named by Name.
+	Latency *LatencyRecorder
+
+	// Stopwatch is an optional Stopwatch which is started by the first Set()
+	// call. When present, its phases are reported by *ExpireError.
+	Stopwatch *Stopwatch
+
+	// PanicHandler is called when some of the OnExpire() hooks panics. It is
+	// called from the deferred function, thus debug.Stack() inside it returns
+	// the stack of the panicked hook. If PanicHandler is nil, panic value and
+	// the stack are written to the os.Stderr.
+	//
+	// Panicked hook does not prevent other hooks from being called.
+	PanicHandler func(interface{})
+
+	// Panics defines how panics of the callbacks run by Do() are handled. It
+	// can be overridden for a single call by WithPanicMode() option. Zero
+	// value means PanicCrash.
+	Panics PanicMode
+
+	// Observer is an optional function which receives lifecycle events of
+	// the deadline. It is called synchronously from the goroutine making the
+	// transition (including the timer goroutine), thus it must not block.
+	Observer func(Event)
+
+	// OnLate is an optional function called from the callback goroutine when
+	// Do() callback returns after Do() returned due to the expiration. It
+	// receives the operation name and the duration between the exceeded
+	// deadline point and the callback return.
+	OnLate func(name string, late time.Duration)
+
+	// CollectStats enables collection of the deadline statistics returned
+	// by Stats().
+	CollectStats bool
+
+	// Debug enables runtime checks of Deadline usage when non-nil. Detected
+	// misuse is reported by calling Debug with the describing error (such as
+	// *SelfWaitError). Checks are expensive and must not be enabled in
+	// production.
+	Debug func(error)
+
+	// CaptureStack makes Do() to capture the stack of the callback goroutine
+	// when the deadline expires before the callback returns. Captured stack
+	// is available as the Stack field of *ExpireError returned by Do() in
+	// such case. Note that capturing requires stopping the world to dump all
+	// goroutines.
+	CaptureStack bool
+
+	// CaptureStackRate makes stack to be captured only for randomly chosen
+	// one of every CaptureStackRate expirations on average when CaptureStack
+	// is true. Zero or one means that stack is captured on every expiration.
+	CaptureStackRate int
+
+	// RichErrors makes Do() to return *ExpireError instead of ErrDeadline.
+	// It is implied when CaptureStack is true, Stopwatch is set or the call
+	// is named (see Name and WithName()).
+	RichErrors bool
+
+	mu      sync.Mutex
+	done    chan struct{}
+	state   atomic.Pointer[state] // Published d.done and d.at for lock-free access.
+	gen     uint64                // Generation of d.done. Preserved by Release().
+	timer   Timer
+	armed   bool   // Whether timer is scheduled and not stopped yet.
+	epoch   uint64 // Timer epoch to detect stale timer calls.
+	hooking int32  // Number of running expirations. Accessed atomically.
+	idle    *sync.Cond
+	pooled  bool  // Whether d is released to the pool.
+	cause   error // Reason of the expiration returned by Do() if set.
+	at      time.Time
+	hooks   []func()
+	once    []*onceHook       // Hooks registered by AfterExpire().
+	calls   map[uint64][]byte // Running callbacks in debug mode.
+	running map[*call]struct{}
+	managed bool     // Whether d is registered at d.Manager.
+	link    *link    // Link to the parent deadline; see NewChild().
+	merge   *merge   // Set if d is created by Earliest().
+	merges  []*merge // Merges watching d; see Earliest().
+
+	warnings  []*warning
+	expireErr error // Error set by SetExpireError().
+	stats     stats
+
+	// Fields below are guarded by Manager.mu.
+	bucket *bucket
+	index  int   // Index in the bucket.
+	tick   int64 // Expiration tick of the Manager's timing wheel.
+}
+
+// call represents single callback run by Do().
+type call struct {
+	name  string
+	start time.Time
+	id    uint64 // Callback goroutine id. Accessed atomically.
+	took  int64  // Callback duration. Accessed atomically.
 }
 
 // Do runs callback in a separate goroutine. It returns when callcack returns
-// or when deadline exceeded. In case of deadline, it returns ErrDeadline.
+// or when deadline exceeded. In case of deadline, it returns ErrDeadline (or
+// *ExpireError, which unwraps to ErrDeadline, when RichErrors or CaptureStack
+// is true, Stopwatch is set or the call is named).
 // In other cases returned error is always nil.
-func (d *Deadline) Do(cb func()) error {
+//
+// Given options allow to differentiate calls made under the same Deadline.
+func (d *Deadline) Do(cb func(), opts ...Option) (err error) {
+	var o callOptions
+	o.apply(opts)
+	if o.name == "" {
+		o.name = d.Name
+	}
+	if o.goer == nil && o.rgoer == nil {
+		o.goer, o.rgoer = d.Goer, d.RejectGoer
+	}
+	if o.err == nil {
+		d.mu.Lock()
+		o.err = d.expireError()
+		d.mu.Unlock()
+	}
+	if o.panics == PanicDefault {
+		o.panics = d.Panics
+	}
+	if d.Debug != nil {
+		d.checkSelfWait("Do")
+		cb = d.trackCall(cb)
+	}
+	var (
+		late      *lateTracker
+		trackLate = d.OnLate != nil || d.CollectStats
+		leaks     = registryEnabled()
+	)
+	if t := o.trace; trackLate || leaks || t != nil && t.Late != nil {
+		late = new(lateTracker)
+		cb = late.wrap(func(n time.Duration) {
+			if trackLate {
+				d.lateDone(o.name, n)
+			}
+			if leaks {
+				unregisterAbandoned(late)
+			}
+			if t != nil && t.Late != nil {
+				t.Late(n)
+			}
+		}, cb)
+	}
+	if t := o.trace; t != nil {
+		if t.Done != nil {
+			defer func() {
+				t.Done(err)
+			}()
+		}
+		if t.Start != nil || t.Return != nil {
+			cb = traceCall(t, cb)
+		}
+	}
+	outcome := OutcomeExpired // Updated if callback returns in time.
+	if d.Latency != nil {
+		start := time.Now()
+		defer func() {
+			d.Latency.Record(o.name, time.Since(start), outcome)
+		}()
+	}
+	var (
+		c     *call
+		start time.Time
+	)
+	rich := d.RichErrors || d.CaptureStack || d.Stopwatch != nil || o.name != ""
+	if rich || d.observed() {
+		start = time.Now()
+	}
+	if rich || d.Manager != nil {
+		c = &call{name: o.name}
+		cb = d.trackRunning(c, cb)
+	}
+	var expired <-chan time.Time
+	if !o.deadline.IsZero() {
+		t := d.clock().NewTimer(o.deadline.Sub(d.now()))
+		defer t.Stop()
+		expired = t.Chan()
+	}
 	var (
-		done = d.Done()
-		ok   = acquireDone()
+		done = d.doneChan()
+		// NOTE: ok channel is closed when callback returns and so could not
+		// be reused via donePool.
+		ok = makeChan()
+
+		panicErr *PanicError
+		rejected chan struct{}
 	)
-	goer(d.Goer, done, func() {
+	task := func() {
 		defer close(ok)
+		if o.panics > PanicCrash {
+			defer func() {
+				if v := recover(); v != nil {
+					panicErr = &PanicError{
+						Value: v,
+						Stack: debug.Stack(),
+					}
+				}
+			}()
+		}
 		cb()
-	})
+	}
+	if o.rgoer != nil {
+		rejected = makeChan()
+		var once sync.Once
+		o.rgoer(done, task, func() {
+			once.Do(func() { close(rejected) })
+		})
+	} else {
+		goer(o.goer, done, task)
+	}
+	var (
+		at       time.Time
+		canceled bool
+	)
 	select {
+	case <-rejected:
+		return ErrRejected
 	case <-ok:
-		releaseDone(ok)
+		outcome = OutcomeOK
+		if panicErr != nil {
+			outcome = OutcomePanicked
+		}
+		if d.observed() {
+			e := Event{
+				Type: EventComplete,
+				Name: o.name,
+				Took: time.Since(start),
+			}
+			if panicErr != nil {
+				e.Err = panicErr
+			}
+			d.emit(e)
+		}
+		if panicErr != nil {
+			if o.panics == PanicRepanic {
+				panic(panicErr)
+			}
+			return panicErr
+		}
 		return nil
+	case <-expired:
+		at = o.deadline
 	case <-done:
-		return ErrDeadline
+		d.mu.Lock()
+		d.sync()
+		at = d.at
+		if d.cause != nil {
+			o.err = d.cause
+			canceled = true
+		}
+		d.mu.Unlock()
+	}
+	if !canceled && isClosed(rejected) {
+		// Goer dropped the callback right before the expiration.
+		o.err = ErrRejected
+	} else if o.grace > 0 {
+		t := d.clock().NewTimer(o.grace)
+		select {
+		case <-ok:
+		case <-t.Chan():
+			if o.abandon != nil {
+				o.abandon()
+			}
+		}
+		t.Stop()
+	}
+	if late != nil {
+		var abandon func()
+		if trackLate || leaks {
+			abandon = func() {
+				if trackLate {
+					d.abandon()
+				}
+				if leaks {
+					registerAbandoned(late, o.name, at)
+				}
+			}
+		}
+		late.expire(at, abandon)
+	}
+	if t := o.trace; t != nil && t.Expire != nil {
+		t.Expire(at)
+	}
+	if !rich {
+		return o.err
+	}
+	e := &ExpireError{
+		Label:    o.name,
+		Deadline: at,
+		Start:    start,
+		Wait:     time.Since(start),
+		err:      o.err,
+		call:     c,
+	}
+	id := atomic.LoadUint64(&c.id)
+	if d.CaptureStack && id != 0 && sample(d.CaptureStackRate) {
+		e.Stack = goroutineStack(id)
+	}
+	if sw := d.Stopwatch; sw != nil {
+		e.Phases = sw.Phases()
+		e.Elapsed = sw.Elapsed()
+	}
+	return e
+}
+
+func traceCall(t *Trace, cb func()) func() {
+	return func() {
+		if t.Start != nil {
+			t.Start()
+		}
+		if t.Return != nil {
+			start := time.Now()
+			defer func() {
+				t.Return(time.Since(start))
+			}()
+		}
+		cb()
 	}
 }
 
 // Done returns a channel which closure means deadline expiration.
+//
+// Repeated calls between Set() calls return the same channel without any
+// locking or allocation, so it is cheap to call Done() in hot select loops.
 func (d *Deadline) Done() <-chan struct{} {
+	return d.doneChan()
+}
+
+func (d *Deadline) doneChan() chan struct{} {
+	done, _ := d.doneGen()
+	return done
+}
+
+// DoneGen returns the same channel as Done() along with its generation.
+// Generation is incremented every time the deadline replaces its done
+// channel: when it is set again after expiration, when it is cleared with
+// wake (see Clear()) or when it is reused after Release(). Done channels are
+// never recycled, so closure of the channel always relates to the
+// generation it was returned with.
+func (d *Deadline) DoneGen() (done <-chan struct{}, gen uint64) {
+	return d.doneGen()
+}
+
+func (d *Deadline) doneGen() (chan struct{}, uint64) {
+	// Fast path.
+	if s := d.state.Load(); s != nil && s.done != nil {
+		return s.done, s.gen
+	}
 	d.mu.Lock()
 	if d.done == nil {
-		d.done = acquireDone()
+		d.setDone(d.newDone())
 	}
-	done := d.done
+	done, gen := d.done, d.gen
 	d.mu.Unlock()
-	return done
+	return done, gen
+}
+
+// Current reports whether gen is the generation of the current done channel.
+// Waiter which observed closure of the channel returned by DoneGen() may use
+// it to check whether the deadline was re-armed (or released) since then.
+func (d *Deadline) Current(gen uint64) bool {
+	if s := d.state.Load(); s != nil && s.done != nil {
+		return s.gen == gen
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.done != nil && d.gen == gen
+}
+
+// String returns short description of d, which includes its name if any.
+func (d *Deadline) String() string {
+	if d.Name == "" {
+		return fmt.Sprintf("deadline %p", d)
+	}
+	return fmt.Sprintf("deadline %q", d.Name)
+}
+
+// Err returns nil if the deadline is not expired yet. Otherwise it returns
+// the expiration reason: the error given to Cancel() or ErrDeadline. That is,
+// Err() is much like context.Context's Err().
+func (d *Deadline) Err() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.done == nil {
+		return nil
+	}
+	select {
+	case <-d.done:
+	default:
+		return nil
+	}
+	if d.cause != nil {
+		return d.cause
+	}
+	return d.expireError()
+}
+
+// SetExpireError sets an error returned by Do() and Err() instead of
+// ErrDeadline when the deadline expires. Returned error wraps err, matches
+// ErrDeadline in terms of errors.Is() and reports true from its Timeout() and
+// Temporary() methods. Nil err resets it back to the ErrDeadline.
+//
+// Note that WithError() option takes precedence over err.
+func (d *Deadline) SetExpireError(err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err == nil || errors.Is(err, ErrDeadline) {
+		d.expireErr = err
+		return
+	}
+	d.expireErr = &causeError{
+		cause:  err,
+		target: ErrDeadline,
+	}
+}
+
+// expireError returns error describing d expiration. It must be called with
+// d.mu held.
+func (d *Deadline) expireError() error {
+	if d.expireErr != nil {
+		return d.expireErr
+	}
+	return ErrDeadline
+}
+
+// setDone sets up new done channel. It must be called with d.mu held.
+func (d *Deadline) setDone(ch chan struct{}) {
+	d.done = ch
+	d.gen++
+	d.publish()
+}
+
+// state is an immutable snapshot of the deadline published for the lock-free
+// paths of Done(), Set() and Expires().
+//
+// When lazy is true, Set() with a later deadline point replaces the state
+// without locking d.mu and without touching the timer. Timer fires at the
+// previous deadline point then and re-arms itself for the remaining time.
+// That is, the common pattern of pushing the deadline forward on every
+// message costs a single CAS.
+type state struct {
+	done chan struct{}
+	gen  uint64
+	at   time.Time
+	lazy bool
+}
+
+// publish publishes current d.done and d.at. It must be called with d.mu
+// held.
+func (d *Deadline) publish() {
+	d.state.Store(&state{
+		done: d.done,
+		gen:  d.gen,
+		at:   d.at,
+		lazy: d.armed && d.lazy(),
+	})
+}
+
+// lazy reports whether armed d can be extended by the lock-free Set(). Only
+// deadlines which do not need to observe every Set() call are extended
+// lazily. It must be called with d.mu held.
+func (d *Deadline) lazy() bool {
+	return d.Manager == nil &&
+		d.Stopwatch == nil &&
+		d.Observer == nil &&
+		d.Debug == nil &&
+		!d.CollectStats &&
+		!d.Monotonic &&
+		len(d.warnings) == 0 &&
+		len(d.merges) == 0 &&
+		!registryEnabled()
+}
+
+// extend tries to move the deadline point of lazily armed d to t without
+// locking. It returns false if the slow path must be taken.
+func (d *Deadline) extend(t time.Time) bool {
+	s := d.state.Load()
+	if s == nil || !s.lazy || !t.After(s.at) {
+		return false
+	}
+	return d.state.CompareAndSwap(s, &state{
+		done: s.done,
+		gen:  s.gen,
+		at:   t,
+		lazy: true,
+	})
+}
+
+// claim disables lazy extension of d and returns its actual deadline point.
+// After claim() returns, published state is changed only under d.mu.
+func (d *Deadline) claim() time.Time {
+	for {
+		s := d.state.Load()
+		if s == nil {
+			return time.Time{}
+		}
+		if !s.lazy {
+			return s.at
+		}
+		if d.state.CompareAndSwap(s, &state{done: s.done, gen: s.gen, at: s.at}) {
+			return s.at
+		}
+	}
+}
+
+// sync brings d.at up to date with lazy extensions made by Set() and
+// disables them until d is armed again. It must be called with d.mu held.
+func (d *Deadline) sync() {
+	if d.state.Load() != nil {
+		d.at = d.claim()
+	}
 }
 
 // Set sets up new deadline point. If previous deadline was not reached yet,
 // but Done() channel was retreived before this Set(), that channel will be
 // closed when new deadline will be expired.
 //
-// It is safe to call Set() from different goroutines.
+// It is safe to call Set() from different goroutines. Moving the deadline
+// point of the armed deadline forward does not take any locks unless
+// Manager, Stopwatch, Observer, Debug, CollectStats, Monotonic, Before()
+// warnings or Earliest() are used.
 func (d *Deadline) Set(t time.Time) {
+	d.checkClock()
+	if d.link != nil {
+		t = d.link.bind(d, t)
+	}
+	if d.extend(t) {
+		return
+	}
+	if d.Stopwatch != nil {
+		d.Stopwatch.startOnce()
+	}
+	d.mu.Lock()
+	if d.pooled {
+		d.mu.Unlock()
+		panic("deadline: Set() of released Deadline")
+	}
+	expired := d.set(t)
+	at, merges := d.at, d.merges
+	d.mu.Unlock()
+	d.emit(Event{Type: EventSet, At: at})
+	if expired {
+		d.runHooks()
+	}
+	notify(merges)
+}
+
+// SetTimeout sets up the deadline to expire after given duration. It is the
+// same as Set(time.Now().Add(timeout)); resulting deadline point carries the
+// monotonic clock reading, thus its expiration is not affected by the wall
+// clock adjustments.
+func (d *Deadline) SetTimeout(timeout time.Duration) {
+	d.Set(d.now().Add(timeout))
+}
+
+// Elapsed returns time elapsed since the Stopwatch start. It returns zero if
+// there is no Stopwatch attached.
+func (d *Deadline) Elapsed() time.Duration {
+	if d.Stopwatch == nil {
+		return 0
+	}
+	return d.Stopwatch.Elapsed()
+}
+
+// Checkpoint records labeled phase at the attached Stopwatch. It does nothing
+// if there is no Stopwatch attached.
+func (d *Deadline) Checkpoint(label string) {
+	if d.Stopwatch != nil {
+		d.Stopwatch.Checkpoint(label)
+	}
+}
+
+// Expires returns currently configured deadline point. It returns zero time
+// if deadline is not set.
+func (d *Deadline) Expires() time.Time {
+	if s := d.state.Load(); s != nil && s.lazy {
+		return s.at
+	}
 	d.mu.Lock()
 	defer d.mu.Unlock()
+	d.sync()
+	return d.at
+}
+
+// Remaining returns duration left until the deadline. It returns -1 if
+// deadline is not set and zero if it is already reached.
+func (d *Deadline) Remaining() time.Duration {
+	at := d.Expires()
+	if at.IsZero() {
+		return -1
+	}
+	if n := at.Sub(d.now()); n > 0 {
+		return n
+	}
+	return 0
+}
+
+// OnExpire registers fn to be called every time the deadline expires. Hooks
+// are called in order of registration from the timer goroutine, or from the
+// Set() caller's goroutine when given time is already in the past.
+//
+// It is safe to call Set() and OnExpire() from within the hook. That is, hook
+// may re-arm the deadline, for example, with a shorter retry interval.
+func (d *Deadline) OnExpire(fn func()) {
+	d.mu.Lock()
+	d.hooks = append(d.hooks, fn)
+	d.mu.Unlock()
+}
 
+// AfterExpire registers fn to be called once, when the deadline expires next
+// time. Unlike OnExpire() hooks, fn is forgotten after the call. Such hooks
+// are called after the OnExpire() ones, in order of registration.
+//
+// Returned stop function unregisters fn. It returns true if the call
+// prevented fn from being called.
+func (d *Deadline) AfterExpire(fn func()) (stop func() bool) {
+	h := &onceHook{fn: fn}
+	d.mu.Lock()
+	d.once = append(d.once, h)
+	d.mu.Unlock()
+	return func() bool {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		for i, x := range d.once {
+			if x == h {
+				d.once = append(d.once[:i], d.once[i+1:]...)
+				return true
+			}
+		}
+		return false
+	}
+}
+
+type onceHook struct {
+	fn func()
+}
+
+// takeHooks returns hooks to be called on expiration. It must be called with
+// d.mu held.
+func (d *Deadline) takeHooks() []func() {
+	if len(d.once) == 0 {
+		return d.hooks
+	}
+	hooks := make([]func(), 0, len(d.hooks)+len(d.once))
+	hooks = append(hooks, d.hooks...)
+	for _, h := range d.once {
+		hooks = append(hooks, h.fn)
+	}
+	d.once = nil
+	return hooks
+}
+
+// set sets up new deadline point. It returns true if deadline is already
+// exceeded and d.done was closed. It must be called with d.mu held.
+func (d *Deadline) set(t time.Time) (expired bool) {
 	// We need to guarantee that nobody else owns d.done for writing.
-	if d.timer != nil && !d.timer.Stop() {
+	//
+	// Note that we check d.armed here because Stop() returns false for the
+	// timer which was stopped before, and nobody would close d.done then.
+	d.sync()
+	if d.armed && !d.disarm() {
+		d.disarmFailed()
+	}
+	n, expired := d.reset(t)
+	if n > 0 {
+		d.arm(d.at, n)
+		d.armed = true
+		d.register()
+		d.updateManager()
+	}
+	d.publish()
+	return expired
+}
+
+// disarmFailed handles the case when d expiration was started but not
+// finished before d was disarmed. It must be called with d.mu held.
+func (d *Deadline) disarmFailed() {
+	if d.scheduler() != nil {
+		// Manager is expiring d right now. That is fine to wait here,
+		// because Manager closes d.done without locking d.mu.
 		<-d.done
+	} else {
+		// Timer is fired, but expire() is not called yet. Make its call
+		// no-op and drop the timer to not reuse it with stale epoch.
+		d.epoch++
+		d.timer = nil
 	}
+}
+
+// reset sets up disarmed d to expire at t. It returns positive duration
+// until the expiration if d must be armed. It returns true if deadline is
+// already exceeded and d.done was closed. It must be called with d.mu held.
+func (d *Deadline) reset(t time.Time) (n time.Duration, expired bool) {
+	d.armed = false
+	if d.Monotonic && !t.IsZero() {
+		t = monotonic(d.now(), t)
+	}
+	d.at = t
+	d.cause = nil
+	d.armWarnings()
 	if t.IsZero() {
-		return
+		d.updateManager()
+		return 0, false
 	}
 	if d.done == nil {
-		d.done = acquireDone()
+		d.setDone(d.newDone())
 	} else {
 		select {
 		case <-d.done:
 			// If done become closed, we need to reinitiate it by a new struct.
 
-			// Writing d.done is safe here without synchronization because we
-			// always await for the timer goroutine exit or timer stop (see
-			// d.timer.Stop() above).
-			d.done = acquireDone()
+			// Replacing d.done is safe here because stale timer calls are
+			// no-op (see d.epoch above) and Manager's expiration is awaited.
+			d.setDone(d.newDone())
 		default:
 		}
 	}
-	n := t.Sub(time.Now())
-	if n < 0 {
+	n = t.Sub(d.now())
+	if n <= 0 {
 		// Close d.done immediately because deadline already exceeded.
+		atomic.AddInt32(&d.hooking, 1)
 		close(d.done)
+		d.updateManager()
+		return 0, true
+	}
+	return n, false
+}
+
+// monotonic returns t as an offset from now. If now has monotonic clock
+// reading, returned time has it as well.
+func monotonic(now, t time.Time) time.Time {
+	return now.Add(t.Sub(now))
+}
+
+// arm schedules expiration of d at t, which is n after now.
+func (d *Deadline) arm(t time.Time, n time.Duration) {
+	if m := d.scheduler(); m != nil {
+		m.schedule(d, t)
 		return
 	}
 	if d.timer == nil {
-		d.timer = time.AfterFunc(n, func() {
-			close(d.done)
+		count(&poolStats.Timers)
+		epoch := d.epoch
+		d.timer = d.clock().AfterFunc(n, func() {
+			d.expire(epoch)
 		})
 	} else {
 		// We do not check d.timer.Stop() here cause it is not a problem, if
@@ -109,12 +847,312 @@ func (d *Deadline) Set(t time.Time) {
 	}
 }
 
+// expireWithCause expires d immediately. Do() calls which are interrupted by
+// this expiration return given cause instead of ErrDeadline.
+func (d *Deadline) expireWithCause(cause error) {
+	d.mu.Lock()
+	expired := d.set(d.now())
+	d.cause = cause
+	merges := d.merges
+	d.mu.Unlock()
+	if expired {
+		d.runHooks()
+	}
+	notify(merges)
+}
+
+// Cancel expires the deadline immediately. Do() calls interrupted by this
+// expiration return given reason instead of ErrDeadline. If reason is nil,
+// ErrCanceled is used.
+func (d *Deadline) Cancel(reason error) {
+	if reason == nil {
+		reason = ErrCanceled
+	}
+	d.expireWithCause(reason)
+}
+
+// scheduler returns Manager which schedules expiration of d. It returns nil
+// if d uses its own timer.
+func (d *Deadline) scheduler() *Manager {
+	if d.Manager != nil {
+		return d.Manager
+	}
+	if d.Clock != nil {
+		return nil
+	}
+	return defaultScheduler
+}
+
+// disarm cancels scheduled expiration of d. It returns false if expiration
+// has been already started.
+func (d *Deadline) disarm() bool {
+	if m := d.scheduler(); m != nil {
+		return m.unschedule(d)
+	}
+	return d.timer.Stop()
+}
+
+// Stop disarms the deadline. It returns true if the call prevents deadline
+// from expiration and false if the deadline has been already expired (and
+// thus OnExpire() hooks are called or being called) or was not set.
+//
+// That is, Stop() has the same semantics as time.Timer's Stop() and lets
+// callers to decide whether some compensating actions are needed.
+func (d *Deadline) Stop() bool {
+	if d.link != nil {
+		d.link.unlink()
+	}
+	d.mu.Lock()
+	d.sync()
+	if !d.armed || !d.disarm() {
+		d.mu.Unlock()
+		return false
+	}
+	d.armed = false
+	d.at = time.Time{}
+	d.armWarnings()
+	d.updateManager()
+	d.publish()
+	merges := d.merges
+	d.mu.Unlock()
+	d.emit(Event{Type: EventSet})
+	notify(merges)
+	return true
+}
+
+// expire is called by the timer when deadline is reached. Given epoch is the
+// d.epoch at the moment of timer creation.
+func (d *Deadline) expire(epoch uint64) {
+	d.mu.Lock()
+	if epoch != d.epoch || !d.armed {
+		// Stale timer call. Deadline was re-armed after timer fired but
+		// before we locked the mutex.
+		d.mu.Unlock()
+		return
+	}
+	prev := d.at
+	d.sync()
+	if d.at.After(prev) {
+		// Deadline was extended by the lock-free Set().
+		if n := d.at.Sub(d.now()); n > 0 {
+			d.arm(d.at, n)
+			d.publish()
+			d.mu.Unlock()
+			return
+		}
+	}
+	d.armed = false
+	atomic.AddInt32(&d.hooking, 1)
+	close(d.done)
+	hooks := d.takeHooks()
+	at, cause := d.at, d.cause
+	d.updateManager()
+	d.mu.Unlock()
+
+	d.emitExpire(at, cause)
+	d.callHooks(hooks)
+	d.hooksDone()
+}
+
+// runHooks calls expiry hooks. Caller must increment d.hooking before
+// closing d.done.
+func (d *Deadline) runHooks() {
+	d.mu.Lock()
+	d.sync()
+	hooks := d.takeHooks()
+	at, cause := d.at, d.cause
+	d.updateManager()
+	d.mu.Unlock()
+	d.emitExpire(at, cause)
+	d.callHooks(hooks)
+	d.hooksDone()
+}
+
+// hooksDone must be called when expiry hooks are finished.
+func (d *Deadline) hooksDone() {
+	if atomic.AddInt32(&d.hooking, -1) == 0 {
+		d.mu.Lock()
+		if d.idle != nil {
+			d.idle.Broadcast()
+		}
+		d.mu.Unlock()
+	}
+}
+
+// Clear disarms the deadline. It is the same as Set() with zero time, except
+// when wake is true: then goroutines waiting on the previously returned Done()
+// channel are released and interrupted Do() calls return ErrCleared. Note
+// that OnExpire() hooks are not called in that case, and Done() called after
+// Clear() returns a new channel which is not closed until the deadline is set
+// again.
+func (d *Deadline) Clear(wake bool) {
+	d.mu.Lock()
+	if d.pooled {
+		d.mu.Unlock()
+		panic("deadline: Clear() of released Deadline")
+	}
+	d.clear(wake)
+	merges := d.merges
+	d.mu.Unlock()
+	if d.link != nil {
+		d.link.unlink()
+	}
+	d.emit(Event{Type: EventSet})
+	notify(merges)
+}
+
+// clear implements Clear(). It must be called with d.mu held.
+func (d *Deadline) clear(wake bool) {
+	d.set(time.Time{})
+	if !wake || d.done == nil {
+		return
+	}
+	select {
+	case <-d.done:
+		// Already expired.
+		return
+	default:
+	}
+	d.cause = ErrCleared
+	close(d.done)
+	d.setDone(d.newDone())
+}
+
+// ClearAndWait disarms the deadline and waits for the running expiry hooks
+// to finish. After it returns, no expiry notification related to the
+// previously armed deadline will be delivered: Done() channel will not be
+// closed and hooks will not be called until the deadline is set again.
+//
+// ClearAndWait must not be called from the expiry hook.
+func (d *Deadline) ClearAndWait() {
+	if d.Debug != nil {
+		d.checkSelfWait("ClearAndWait")
+	}
+	if d.link != nil {
+		d.link.unlink()
+	}
+	d.mu.Lock()
+	d.set(time.Time{})
+	d.waitHooks()
+	merges := d.merges
+	d.mu.Unlock()
+	d.emit(Event{Type: EventSet})
+	notify(merges)
+}
+
+// ExpireAndWait expires the deadline immediately and waits for all expiry
+// hooks to finish, including ones which are run due to previous expirations.
+// It returns ctx.Err() if ctx is done before that. Note that hooks of this
+// expiration are called synchronously and so are not interrupted by ctx.
+//
+// It is intended to be used as deterministic barrier in tests and shutdown
+// code. It must not be called from the expiry hook.
+func (d *Deadline) ExpireAndWait(ctx context.Context) error {
+	if d.Debug != nil {
+		d.checkSelfWait("ExpireAndWait")
+	}
+	d.Set(d.now())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		d.waitHooks()
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// waitHooks waits for running expiry hooks to finish. It must be called with
+// d.mu held.
+func (d *Deadline) waitHooks() {
+	for atomic.LoadInt32(&d.hooking) > 0 {
+		if d.idle == nil {
+			d.idle = sync.NewCond(&d.mu)
+		}
+		d.idle.Wait()
+	}
+}
+
+func (d *Deadline) callHooks(hooks []func()) {
+	for _, fn := range hooks {
+		d.callHook(fn)
+	}
+}
+
+func (d *Deadline) callHook(fn func()) {
+	defer func() {
+		if err := recover(); err != nil {
+			if h := d.PanicHandler; h != nil {
+				h(err)
+			} else {
+				fmt.Fprintf(os.Stderr,
+					"deadline: panic in expiry hook: %v\n%s", err, debug.Stack(),
+				)
+			}
+		}
+	}()
+	fn()
+}
+
+// trackRunning wraps given callback such that it becomes registered as running
+// while it executes.
+func (d *Deadline) trackRunning(c *call, cb func()) func() {
+	return func() {
+		c.start = time.Now()
+		atomic.StoreUint64(&c.id, goid())
+		if d.Manager != nil {
+			d.mu.Lock()
+			if d.running == nil {
+				d.running = make(map[*call]struct{})
+			}
+			d.running[c] = struct{}{}
+			d.updateManager()
+			d.mu.Unlock()
+			defer func() {
+				d.mu.Lock()
+				delete(d.running, c)
+				d.updateManager()
+				d.mu.Unlock()
+			}()
+		}
+		defer func() {
+			atomic.StoreInt64(&c.took, int64(time.Since(c.start)))
+		}()
+		cb()
+	}
+}
+
+// sample reports whether event must be sampled with given rate.
+func sample(rate int) bool {
+	return rate <= 1 || rand.Intn(rate) == 0
+}
+
 // GoFunc runs given callback in a separate goroutine. If by any reason it is
 // not possible to start new goroutine, and the given cancelation channel
 // become non-empty (closed) implementation must not try to start the goroutine
 // and exit immediately.
 type GoFunc func(<-chan struct{}, func())
 
+// RejectGoFunc is like GoFunc, but it reports that the callback is dropped
+// by calling the reject function instead of the callback. See
+// WithRejectGoer().
+type RejectGoFunc func(cancel <-chan struct{}, task, reject func())
+
+// start starts task by d's RejectGoer or Goer. Given reject is called if
+// task is dropped by the RejectGoer.
+func (d *Deadline) start(cancel <-chan struct{}, task, reject func()) {
+	if d.RejectGoer != nil {
+		d.RejectGoer(cancel, task, reject)
+	} else {
+		goer(d.Goer, cancel, task)
+	}
+}
+
 func goer(g GoFunc, cancel <-chan struct{}, task func()) {
 	if g == nil {
 		go task()
@@ -123,21 +1161,34 @@ func goer(g GoFunc, cancel <-chan struct{}, task func()) {
 	}
 }
 
+// isClosed reports whether ch is closed. It returns false for nil channel.
+func isClosed(ch chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
+
+type rejectedError struct{}
+
+func (rejectedError) Error() string   { return "deadline exceeded: task rejected" }
+func (rejectedError) Timeout() bool   { return true }
+func (rejectedError) Temporary() bool { return true }
+func (rejectedError) Is(target error) bool {
+	return target == ErrDeadline || ErrDeadline.Is(target)
+}
+
 type deadlineError struct{ error }
 
 func (d deadlineError) Error() string   { return "deadline exceeded" }
 func (d deadlineError) Timeout() bool   { return true }
 func (d deadlineError) Temporary() bool { return true }
 
-var donePool sync.Pool
-
-func acquireDone() chan struct{} {
-	if v := donePool.Get(); v != nil {
-		return v.(chan struct{})
-	}
-	return make(chan struct{})
-}
-
-func releaseDone(ch chan struct{}) {
-	donePool.Put(ch)
+// Is makes ErrDeadline to match standard timeout errors in terms of
+// errors.Is().
+func (d deadlineError) Is(target error) bool {
+	return target == context.DeadlineExceeded ||
+		target == os.ErrDeadlineExceeded
 }
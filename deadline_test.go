@@ -1,6 +1,9 @@
 package deadline
 
 import (
+	"context"
+	"errors"
+	"strings"
 	"testing"
 	"time"
 )
@@ -38,3 +41,448 @@ func TestDeadline(t *testing.T) {
 		})
 	}
 }
+
+func TestDeadlineOnExpirePanic(t *testing.T) {
+	var (
+		d      Deadline
+		called = make(chan int, 3)
+		panics = make(chan interface{}, 1)
+	)
+	d.PanicHandler = func(v interface{}) {
+		panics <- v
+	}
+	d.OnExpire(func() { called <- 1 })
+	d.OnExpire(func() { panic("boom") })
+	d.OnExpire(func() { called <- 3 })
+
+	d.Set(time.Now().Add(time.Millisecond))
+	<-d.Done()
+
+	for _, exp := range []int{1, 3} {
+		select {
+		case act := <-called:
+			if act != exp {
+				t.Errorf("unexpected hook call: %d; want %d", act, exp)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("hook %d was not called", exp)
+		}
+	}
+	if v := <-panics; v != "boom" {
+		t.Errorf("unexpected panic value: %v", v)
+	}
+}
+
+func TestDeadlineSetFromHook(t *testing.T) {
+	var (
+		d     Deadline
+		fired = make(chan struct{}, 2)
+		retry = true
+	)
+	d.OnExpire(func() {
+		fired <- struct{}{}
+		if retry {
+			retry = false
+			d.Set(time.Now().Add(time.Millisecond))
+		}
+	})
+	d.Set(time.Now().Add(time.Millisecond))
+	for i := 0; i < 2; i++ {
+		select {
+		case <-fired:
+		case <-time.After(time.Second):
+			t.Fatalf("hook was called %d times; want 2", i)
+		}
+	}
+}
+
+func TestDeadlineSetAfterClear(t *testing.T) {
+	var d Deadline
+	d.Set(time.Now().Add(time.Hour))
+	d.Set(time.Time{})
+	d.Set(time.Now().Add(time.Millisecond))
+	select {
+	case <-d.Done():
+	case <-time.After(time.Second):
+		t.Fatalf("deadline was not reached")
+	}
+}
+
+func TestDeadlineCancel(t *testing.T) {
+	errReason := errors.New("reason")
+	for _, test := range []struct {
+		reason error
+		exp    error
+	}{
+		{nil, ErrCanceled},
+		{errReason, errReason},
+	} {
+		var d Deadline
+		d.Set(time.Now().Add(time.Hour))
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			d.Cancel(test.reason)
+		}()
+		err := d.Do(func() {
+			time.Sleep(time.Second)
+		})
+		if err != test.exp {
+			t.Errorf("unexpected error: %v; want %v", err, test.exp)
+		}
+	}
+}
+
+func TestDeadlineErr(t *testing.T) {
+	var d Deadline
+	if err := d.Err(); err != nil {
+		t.Errorf("unexpected error of not set deadline: %v", err)
+	}
+	d.Set(time.Now().Add(10 * time.Millisecond))
+	if err := d.Err(); err != nil {
+		t.Errorf("unexpected error of armed deadline: %v", err)
+	}
+	<-d.Done()
+	if err := d.Err(); err != ErrDeadline {
+		t.Errorf("unexpected error: %v; want %v", err, ErrDeadline)
+	}
+	d.Set(time.Now().Add(time.Hour))
+	ctx := d.Context()
+	d.Cancel(nil)
+	if err := d.Err(); err != ErrCanceled {
+		t.Errorf("unexpected error: %v; want %v", err, ErrCanceled)
+	}
+	if err := ctx.Err(); err != context.Canceled {
+		t.Errorf("unexpected context error: %v; want %v", err, context.Canceled)
+	}
+}
+
+func TestDeadlineRemaining(t *testing.T) {
+	var d Deadline
+	if n := d.Remaining(); n != -1 {
+		t.Errorf("Remaining() = %s for not set deadline; want -1", n)
+	}
+	at := time.Now().Add(time.Hour)
+	d.Set(at)
+	if act := d.Expires(); !act.Equal(at) {
+		t.Errorf("Expires() = %s; want %s", act, at)
+	}
+	if n := d.Remaining(); n <= 0 || n > time.Hour {
+		t.Errorf("unexpected Remaining(): %s", n)
+	}
+	d.Set(time.Now().Add(-time.Second))
+	if n := d.Remaining(); n != 0 {
+		t.Errorf("Remaining() = %s for expired deadline; want 0", n)
+	}
+}
+
+func TestDeadlineClear(t *testing.T) {
+	var d Deadline
+	d.Set(time.Now().Add(10 * time.Millisecond))
+	done := d.Done()
+	d.Clear(false)
+	select {
+	case <-done:
+		t.Fatalf("Done() is closed after Clear(false)")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	d.Set(time.Now().Add(time.Hour))
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		d.Clear(true)
+	}()
+	err := d.Do(func() {
+		time.Sleep(time.Second)
+	})
+	if err != ErrCleared {
+		t.Errorf("unexpected error: %v; want %v", err, ErrCleared)
+	}
+	if err := d.Err(); err != nil {
+		t.Errorf("unexpected error after Clear(true): %v", err)
+	}
+	select {
+	case <-d.Done():
+		t.Errorf("Done() is closed after Clear(true)")
+	default:
+	}
+}
+
+func TestDeadlineSetTimeout(t *testing.T) {
+	var d Deadline
+	d.SetTimeout(10 * time.Millisecond)
+	select {
+	case <-d.Done():
+	case <-time.After(time.Second):
+		t.Fatalf("deadline was not reached")
+	}
+}
+
+func TestDeadlineMonotonic(t *testing.T) {
+	for _, test := range []struct {
+		monotonic bool
+		exp       bool
+	}{
+		{false, false},
+		{true, true},
+	} {
+		d := Deadline{Monotonic: test.monotonic}
+		// Round(0) strips monotonic clock reading.
+		d.Set(time.Now().Add(time.Hour).Round(0))
+		at := d.Expires()
+		if act := strings.Contains(at.String(), "m="); act != test.exp {
+			t.Errorf(
+				"Monotonic=%t: deadline %s has monotonic reading: %t; want %t",
+				test.monotonic, at, act, test.exp,
+			)
+		}
+		d.Stop()
+	}
+}
+
+func TestDeadlineSetExpireError(t *testing.T) {
+	errPayment := errors.New("payment authorization timed out")
+	var d Deadline
+	d.SetExpireError(errPayment)
+	d.Set(time.Now().Add(10 * time.Millisecond))
+	err := d.Do(func() {
+		time.Sleep(50 * time.Millisecond)
+	})
+	if !errors.Is(err, errPayment) || !errors.Is(err, ErrDeadline) {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if !IsTimeout(err) {
+		t.Errorf("error is not a timeout")
+	}
+	if err := d.Err(); !errors.Is(err, errPayment) {
+		t.Errorf("unexpected Err(): %v", err)
+	}
+}
+
+func TestDeadlineName(t *testing.T) {
+	d := Deadline{Name: "payment"}
+	if s := d.String(); s != `deadline "payment"` {
+		t.Errorf("unexpected String(): %q", s)
+	}
+	d.Set(time.Now().Add(10 * time.Millisecond))
+	err := d.Do(func() {
+		time.Sleep(50 * time.Millisecond)
+	})
+	if !errors.Is(err, ErrDeadline) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if s := err.Error(); !strings.HasPrefix(s, "payment: ") {
+		t.Errorf("error text has no deadline name: %q", s)
+	}
+}
+
+func TestDeadlineAfterExpire(t *testing.T) {
+	var (
+		d     Deadline
+		calls int
+	)
+	d.AfterExpire(func() {
+		calls++
+	})
+	stop := d.AfterExpire(func() {
+		t.Errorf("stopped hook was called")
+	})
+	if !stop() {
+		t.Errorf("stop() = false for registered hook")
+	}
+	d.Set(time.Now().Add(-time.Second))
+	d.Set(time.Now().Add(-time.Second))
+	if calls != 1 {
+		t.Errorf("hook was called %d times; want 1", calls)
+	}
+	if stop() {
+		t.Errorf("stop() = true for unregistered hook")
+	}
+}
+
+func TestDeadlineStop(t *testing.T) {
+	var d Deadline
+	if d.Stop() {
+		t.Errorf("Stop() = true for not set deadline")
+	}
+	d.Set(time.Now().Add(time.Hour))
+	if !d.Stop() {
+		t.Errorf("Stop() = false for armed deadline")
+	}
+	d.Set(time.Now().Add(time.Millisecond))
+	<-d.Done()
+	if d.Stop() {
+		t.Errorf("Stop() = true for expired deadline")
+	}
+	d.Set(time.Now().Add(time.Millisecond))
+	select {
+	case <-d.Done():
+	case <-time.After(time.Second):
+		t.Fatalf("deadline re-armed after Stop() was not reached")
+	}
+}
+
+func TestDeadlineDoneCached(t *testing.T) {
+	var d Deadline
+	d.Set(time.Now().Add(time.Hour))
+	done := d.Done()
+	allocs := testing.AllocsPerRun(100, func() {
+		if d.Done() != done {
+			t.Fatalf("Done() returned different channel")
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("Done() allocates %v times per call; want 0", allocs)
+	}
+}
+
+func TestDeadlineDoneGen(t *testing.T) {
+	var d Deadline
+	d.Set(time.Now().Add(time.Hour))
+	done, gen := d.DoneGen()
+	d.Set(time.Now().Add(time.Hour))
+	if !d.Current(gen) {
+		t.Fatalf("generation changed while deadline was not expired")
+	}
+	d.Set(time.Now())
+	<-done
+	if !d.Current(gen) {
+		t.Fatalf("generation changed before deadline was set again")
+	}
+	d.Set(time.Now().Add(time.Hour))
+	if d.Current(gen) {
+		t.Fatalf("generation was not changed after deadline was set again")
+	}
+	if _, next := d.DoneGen(); next <= gen {
+		t.Fatalf("unexpected next generation: %d; previous is %d", next, gen)
+	}
+}
+
+func BenchmarkDeadlineDone(b *testing.B) {
+	var d Deadline
+	d.Set(time.Now().Add(time.Hour))
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			select {
+			case <-d.Done():
+				b.Fatal("unexpected expiration")
+			default:
+			}
+		}
+	})
+}
+
+func TestDeadlineExtend(t *testing.T) {
+	var d Deadline
+	d.Set(time.Now().Add(10 * time.Millisecond))
+	done := d.Done()
+
+	// Push deadline forward while previously armed timer fires.
+	end := time.Now().Add(50 * time.Millisecond)
+	for time.Now().Before(end) {
+		d.Set(time.Now().Add(10 * time.Millisecond))
+		select {
+		case <-done:
+			t.Fatalf("deadline expired while being extended")
+		default:
+		}
+		time.Sleep(time.Millisecond)
+	}
+	at := time.Now().Add(10 * time.Millisecond)
+	d.Set(at)
+	if act := d.Expires(); !act.Equal(at) {
+		t.Errorf("Expires() = %s; want %s", act, at)
+	}
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("extended deadline was not reached")
+	}
+	if d.Done() != done {
+		t.Errorf("Done() channel changed after extension")
+	}
+}
+
+func BenchmarkDeadlineSet(b *testing.B) {
+	var d Deadline
+	d.Set(time.Now().Add(time.Hour))
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			d.Set(time.Now().Add(time.Hour))
+		}
+	})
+}
+
+func TestDeadlineStaleTimer(t *testing.T) {
+	var d Deadline
+	d.Set(time.Now().Add(time.Millisecond))
+
+	// Re-arm deadline while timer is fired but its call is blocked on the
+	// mutex.
+	d.mu.Lock()
+	time.Sleep(10 * time.Millisecond)
+	d.set(time.Now().Add(time.Hour))
+	d.mu.Unlock()
+
+	select {
+	case <-d.Done():
+		t.Fatalf("stale timer call closed re-armed deadline")
+	case <-time.After(10 * time.Millisecond):
+	}
+}
+
+func TestDeadlineClearAndWait(t *testing.T) {
+	for _, m := range []*Manager{nil, new(Manager)} {
+		var (
+			d        = Deadline{Manager: m}
+			started  = make(chan struct{})
+			finished = make(chan struct{})
+		)
+		d.OnExpire(func() {
+			close(started)
+			time.Sleep(10 * time.Millisecond)
+			close(finished)
+		})
+		d.Set(time.Now().Add(time.Millisecond))
+		<-started
+		d.ClearAndWait()
+		select {
+		case <-finished:
+		default:
+			t.Fatalf("ClearAndWait() returned before hook finished")
+		}
+
+		d.Set(time.Now().Add(time.Hour))
+		done := d.Done()
+		d.ClearAndWait()
+		select {
+		case <-done:
+			t.Fatalf("cleared deadline expired")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+func TestDeadlineExpireAndWait(t *testing.T) {
+	var (
+		d     Deadline
+		calls int
+	)
+	d.OnExpire(func() {
+		time.Sleep(5 * time.Millisecond)
+		calls++
+	})
+	d.Set(time.Now().Add(time.Hour))
+	if err := d.ExpireAndWait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 1 {
+		t.Errorf("hook called %d times; want 1", calls)
+	}
+	select {
+	case <-d.Done():
+	default:
+		t.Errorf("deadline is not expired")
+	}
+}
@@ -0,0 +1,36 @@
+package deadlinehttp
+
+import (
+	"net/http"
+
+	"github.com/gobwas/deadline"
+)
+
+// TimeoutHeader is the name of the HTTP header carrying the remaining
+// deadline budget encoded by deadline.FormatTimeout().
+const TimeoutHeader = "Grpc-Timeout"
+
+// Propagate sets the TimeoutHeader of h to the remaining budget of d. It does
+// nothing if d is not set.
+func Propagate(h http.Header, d *deadline.Deadline) {
+	if s, ok := d.EncodeBudget(); ok {
+		h.Set(TimeoutHeader, s)
+	}
+}
+
+// FromRequest returns Deadline set to the budget carried by the
+// TimeoutHeader of r. Returned Deadline also follows r's context (see
+// Deadline's Follow()). If r has no TimeoutHeader, returned Deadline is not
+// set. It returns error if header value is malformed.
+func FromRequest(r *http.Request) (*deadline.Deadline, error) {
+	d := new(deadline.Deadline)
+	if s := r.Header.Get(TimeoutHeader); s != "" {
+		n, err := deadline.ParseTimeout(s)
+		if err != nil {
+			return nil, err
+		}
+		d.SetTimeout(n)
+	}
+	d.Follow(r.Context())
+	return d, nil
+}
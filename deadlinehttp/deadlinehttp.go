@@ -0,0 +1,61 @@
+// Package deadlinehttp provides helpers to respond consistently to the HTTP
+// requests which were failed due to the deadline expiration, to propagate
+// deadline budget across services, and debug handlers exposing armed
+// deadlines.
+package deadlinehttp
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gobwas/deadline"
+)
+
+// Status returns HTTP status code corresponding to the given error. It
+// returns http.StatusGatewayTimeout for timeout errors (see
+// deadline.IsTimeout()), http.StatusServiceUnavailable for cancellation errors
+// (see deadline.IsCanceled()) and zero otherwise.
+func Status(err error) int {
+	switch {
+	case deadline.IsTimeout(err):
+		return http.StatusGatewayTimeout
+	case deadline.IsCanceled(err):
+		return http.StatusServiceUnavailable
+	default:
+		return 0
+	}
+}
+
+// Responder writes HTTP responses for the requests failed due to deadline
+// expiration or cancellation.
+type Responder struct {
+	// Policy is an optional deadline policy used to compute the Retry-After
+	// header value as the timeout suggested for the operation.
+	Policy deadline.Policy
+
+	// RetryAfter is a Retry-After value used when Policy is nil.
+	// If both Policy and RetryAfter are empty, no Retry-After header sent.
+	RetryAfter time.Duration
+}
+
+// Respond writes response for the operation op failed with err. It returns
+// false and writes nothing if err is neither timeout nor cancellation error.
+func (r *Responder) Respond(w http.ResponseWriter, op string, err error) bool {
+	code := Status(err)
+	if code == 0 {
+		return false
+	}
+	retry := r.RetryAfter
+	if r.Policy != nil {
+		retry = r.Policy.Timeout(op)
+	}
+	if retry > 0 {
+		// Retry-After is measured in seconds; round up to not invite client
+		// to retry too early.
+		sec := int64((retry + time.Second - 1) / time.Second)
+		w.Header().Set("Retry-After", strconv.FormatInt(sec, 10))
+	}
+	http.Error(w, http.StatusText(code), code)
+	return true
+}
@@ -0,0 +1,103 @@
+package deadlinehttp
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gobwas/deadline"
+)
+
+func TestResponder(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		r      Responder
+		err    error
+		ok     bool
+		code   int
+		header string
+	}{
+		{
+			name:   "timeout",
+			r:      Responder{RetryAfter: 1500 * time.Millisecond},
+			err:    deadline.ErrDeadline,
+			ok:     true,
+			code:   http.StatusGatewayTimeout,
+			header: "2",
+		},
+		{
+			name:   "policy",
+			r:      Responder{Policy: deadline.Static(3 * time.Second)},
+			err:    context.Canceled,
+			ok:     true,
+			code:   http.StatusServiceUnavailable,
+			header: "3",
+		},
+		{
+			name: "other",
+			err:  io.EOF,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			ok := test.r.Respond(rec, "op", test.err)
+			if ok != test.ok {
+				t.Fatalf("Respond() = %t; want %t", ok, test.ok)
+			}
+			if !ok {
+				return
+			}
+			if rec.Code != test.code {
+				t.Errorf("unexpected status code: %d; want %d", rec.Code, test.code)
+			}
+			if h := rec.Header().Get("Retry-After"); h != test.header {
+				t.Errorf("unexpected Retry-After: %q; want %q", h, test.header)
+			}
+		})
+	}
+}
+
+func TestArmedHandler(t *testing.T) {
+	deadline.EnableRegistry(true)
+	defer deadline.EnableRegistry(false)
+
+	d := deadline.Deadline{Name: "upstream"}
+	d.Set(time.Now().Add(time.Hour))
+	defer d.Stop()
+
+	rec := httptest.NewRecorder()
+	ArmedHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if body := rec.Body.String(); !strings.Contains(body, `deadline "upstream": expires in`) {
+		t.Errorf("unexpected report:\n%s", body)
+	}
+	if s := (ArmedVar{}).String(); !strings.Contains(s, `"name":"upstream"`) {
+		t.Errorf("unexpected var value: %s", s)
+	}
+}
+
+func TestPropagate(t *testing.T) {
+	var d deadline.Deadline
+	d.SetTimeout(time.Minute)
+
+	r := httptest.NewRequest("GET", "/", nil)
+	Propagate(r.Header, &d)
+	if r.Header.Get(TimeoutHeader) == "" {
+		t.Fatalf("timeout header is not set")
+	}
+	x, err := FromRequest(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := x.Remaining(); n > time.Minute || n < time.Minute-time.Second {
+		t.Errorf("unexpected remaining time: %s", n)
+	}
+
+	r.Header.Set(TimeoutHeader, "bad")
+	if _, err := FromRequest(r); err == nil {
+		t.Errorf("expected error for malformed header")
+	}
+}
@@ -0,0 +1,68 @@
+package deadlinehttp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gobwas/deadline"
+)
+
+// ArmedHandler returns http.Handler which writes human-readable report about
+// armed deadlines listed by the debug registry (see
+// deadline.EnableRegistry()), including the stacks which armed them.
+func ArmedHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		var (
+			now   = time.Now()
+			armed = deadline.Armed()
+			bw    = bufio.NewWriter(w)
+		)
+		fmt.Fprintf(bw, "%d armed deadline(s)\n", len(armed))
+		for _, a := range armed {
+			fmt.Fprintf(bw, "\ndeadline %q", a.Name)
+			if len(a.Tags) > 0 {
+				fmt.Fprintf(bw, " [%s]", strings.Join(a.Tags, ","))
+			}
+			fmt.Fprintf(bw, ": expires in %s; armed by:\n", a.Expires.Sub(now))
+			for _, line := range strings.Split(string(a.Stack), "\n") {
+				fmt.Fprintf(bw, "\t%s\n", line)
+			}
+		}
+		bw.Flush()
+	})
+}
+
+// ArmedVar implements expvar.Var interface exposing armed deadlines listed
+// by the debug registry (see deadline.EnableRegistry()) as JSON. It is
+// intended to be published by expvar.Publish().
+type ArmedVar struct{}
+
+// String returns JSON encoded list of armed deadlines.
+func (ArmedVar) String() string {
+	type entry struct {
+		Name    string    `json:"name,omitempty"`
+		Tags    []string  `json:"tags,omitempty"`
+		Expires time.Time `json:"expires"`
+		Stack   string    `json:"stack,omitempty"`
+	}
+	armed := deadline.Armed()
+	es := make([]entry, len(armed))
+	for i, a := range armed {
+		es[i] = entry{
+			Name:    a.Name,
+			Tags:    a.Tags,
+			Expires: a.Expires,
+			Stack:   string(a.Stack),
+		}
+	}
+	p, err := json.Marshal(es)
+	if err != nil {
+		return "null"
+	}
+	return string(p)
+}
@@ -0,0 +1,78 @@
+// Package deadlineleak helps tests to detect Do() callbacks which were
+// abandoned due to the deadline expiration and are still running when the
+// test ends.
+//
+// Detection relies on the deadline's debug registry (see
+// deadline.EnableRegistry()), which is global. Thus Check() must not be used
+// by parallel tests; use VerifyMain() for them instead.
+package deadlineleak
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gobwas/deadline"
+)
+
+// DefaultGrace is the default time given to abandoned callbacks to finish.
+const DefaultGrace = time.Second
+
+// Check enables tracking of abandoned callbacks and registers test cleanup
+// which fails tb if some of them are still running after DefaultGrace.
+func Check(tb testing.TB) {
+	tb.Helper()
+	deadline.EnableRegistry(true)
+	tb.Cleanup(func() {
+		defer deadline.EnableRegistry(false)
+		if calls := wait(DefaultGrace); len(calls) > 0 {
+			tb.Errorf("%s", report(calls))
+		}
+	})
+}
+
+// VerifyMain runs tests with tracking of abandoned callbacks enabled and
+// exits with non-zero code if some of them are still running after
+// DefaultGrace after all tests passed. It is intended to be called from
+// TestMain().
+func VerifyMain(m *testing.M) {
+	deadline.EnableRegistry(true)
+	code := m.Run()
+	if code == 0 {
+		if calls := wait(DefaultGrace); len(calls) > 0 {
+			fmt.Fprintln(os.Stderr, report(calls))
+			code = 1
+		}
+	}
+	os.Exit(code)
+}
+
+// wait waits for abandoned callbacks to finish during grace period. It
+// returns callbacks which are still running after that.
+func wait(grace time.Duration) []deadline.AbandonedCall {
+	end := time.Now().Add(grace)
+	for {
+		calls := deadline.Abandoned()
+		if len(calls) == 0 || time.Now().After(end) {
+			return calls
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func report(calls []deadline.AbandonedCall) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "deadlineleak: %d abandoned callback(s) still running", len(calls))
+	for _, c := range calls {
+		name := c.Name
+		if name == "" {
+			name = "<unnamed>"
+		}
+		fmt.Fprintf(&sb, "\n\ncallback %s abandoned at %s by:\n%s",
+			name, c.Deadline.Format(time.RFC3339Nano), c.Stack,
+		)
+	}
+	return sb.String()
+}
@@ -0,0 +1,34 @@
+package deadlineleak
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gobwas/deadline"
+)
+
+func TestWait(t *testing.T) {
+	deadline.EnableRegistry(true)
+	defer deadline.EnableRegistry(false)
+
+	release := make(chan struct{})
+	defer close(release)
+	d := deadline.Deadline{Name: "stuck"}
+	d.Set(time.Now().Add(10 * time.Millisecond))
+	d.Do(func() {
+		<-release
+	})
+	d.Set(time.Now().Add(10 * time.Millisecond))
+	d.Do(func() {
+		time.Sleep(30 * time.Millisecond)
+	})
+
+	calls := wait(100 * time.Millisecond)
+	if len(calls) != 1 {
+		t.Fatalf("unexpected abandoned callbacks: %d; want 1", len(calls))
+	}
+	if r := report(calls); !strings.Contains(r, "callback stuck abandoned") {
+		t.Errorf("unexpected report:\n%s", r)
+	}
+}
@@ -0,0 +1,236 @@
+// Package deadlinemetrics exports deadline lifecycle metrics in the
+// Prometheus text exposition format.
+//
+// The package does not depend on the Prometheus client library and so does
+// not provide prometheus.Collector implementation: Exporter serves metrics
+// over HTTP by itself, so it can be scraped directly or mounted next to the
+// other handlers of the application.
+package deadlinemetrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gobwas/deadline"
+)
+
+// DefaultBuckets are the default upper bounds (in seconds) of the completion
+// histogram buckets.
+var DefaultBuckets = []float64{
+	.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10,
+}
+
+// Exporter collects metrics of the deadlines it observes:
+//
+//	<namespace>_deadline_expirations_total{reason="expire|cancel",...}
+//	<namespace>_deadline_completion_seconds{...}
+//	<namespace>_deadline_active{...}
+//
+// Metrics are partitioned by the labels given to Observer().
+type Exporter struct {
+	// Namespace is an optional prefix of the metric names.
+	Namespace string
+
+	// Buckets are upper bounds (in seconds) of the completion histogram
+	// buckets. If nil, DefaultBuckets are used.
+	Buckets []float64
+
+	mu     sync.Mutex
+	series map[string]*series
+	active map[*deadline.Deadline]*series
+}
+
+type series struct {
+	labels   string
+	expired  uint64
+	canceled uint64
+	active   int64
+	buckets  []uint64
+	sum      float64
+	count    uint64
+	bounds   []float64
+}
+
+// Observer returns function suitable to be used as the deadline.Deadline's
+// Observer field. Metrics of the observed deadlines are labeled by given
+// labels, which are key-value pairs.
+//
+// Observer() panics if labels are not paired.
+func (c *Exporter) Observer(labels ...string) func(deadline.Event) {
+	if len(labels)%2 != 0 {
+		panic("deadlinemetrics: odd number of labels")
+	}
+	c.mu.Lock()
+	s := c.getSeries(formatLabels(labels))
+	c.mu.Unlock()
+	return func(e deadline.Event) {
+		c.observe(s, e)
+	}
+}
+
+func (c *Exporter) observe(s *series, e deadline.Event) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch e.Type {
+	case deadline.EventSet:
+		c.setActive(s, e.Deadline, !e.At.IsZero())
+	case deadline.EventExpire:
+		s.expired++
+		c.setActive(s, e.Deadline, false)
+	case deadline.EventCancel:
+		s.canceled++
+		c.setActive(s, e.Deadline, false)
+	case deadline.EventComplete:
+		v := e.Took.Seconds()
+		for i, b := range s.bounds {
+			if v <= b {
+				s.buckets[i]++
+			}
+		}
+		s.sum += v
+		s.count++
+	}
+}
+
+type byLabels []*series
+
+func (s byLabels) Len() int           { return len(s) }
+func (s byLabels) Less(i, j int) bool { return s[i].labels < s[j].labels }
+func (s byLabels) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+// setActive updates active state of d. It must be called with c.mu held.
+func (c *Exporter) setActive(s *series, d *deadline.Deadline, active bool) {
+	_, was := c.active[d]
+	switch {
+	case active && !was:
+		if c.active == nil {
+			c.active = make(map[*deadline.Deadline]*series)
+		}
+		c.active[d] = s
+		s.active++
+	case !active && was:
+		delete(c.active, d)
+		s.active--
+	}
+}
+
+// getSeries returns series for given formatted labels. It must be called with
+// c.mu held.
+func (c *Exporter) getSeries(labels string) *series {
+	if s := c.series[labels]; s != nil {
+		return s
+	}
+	if c.series == nil {
+		c.series = make(map[string]*series)
+	}
+	bounds := c.Buckets
+	if bounds == nil {
+		bounds = DefaultBuckets
+	}
+	s := &series{
+		labels:  labels,
+		buckets: make([]uint64, len(bounds)),
+		bounds:  bounds,
+	}
+	c.series[labels] = s
+	return s
+}
+
+// ServeHTTP writes collected metrics in the Prometheus text format.
+func (c *Exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	c.WriteTo(w)
+}
+
+// WriteTo writes collected metrics in the Prometheus text format to w.
+func (c *Exporter) WriteTo(w io.Writer) (int64, error) {
+	c.mu.Lock()
+	all := make([]*series, 0, len(c.series))
+	for _, s := range c.series {
+		cp := *s
+		cp.buckets = append([]uint64(nil), s.buckets...)
+		all = append(all, &cp)
+	}
+	c.mu.Unlock()
+	sort.Sort(byLabels(all))
+
+	cw := &countWriter{w: w}
+	bw := bufio.NewWriter(cw)
+	name := c.name("expirations_total")
+	fmt.Fprintf(bw, "# HELP %s Number of deadline expirations.\n", name)
+	fmt.Fprintf(bw, "# TYPE %s counter\n", name)
+	for _, s := range all {
+		fmt.Fprintf(bw, "%s%s %d\n", name, join(s.labels, `reason="expire"`), s.expired)
+		fmt.Fprintf(bw, "%s%s %d\n", name, join(s.labels, `reason="cancel"`), s.canceled)
+	}
+	name = c.name("completion_seconds")
+	fmt.Fprintf(bw, "# HELP %s Duration of callbacks completed before the deadline.\n", name)
+	fmt.Fprintf(bw, "# TYPE %s histogram\n", name)
+	for _, s := range all {
+		for i, b := range s.bounds {
+			le := `le="` + strconv.FormatFloat(b, 'g', -1, 64) + `"`
+			fmt.Fprintf(bw, "%s_bucket%s %d\n", name, join(s.labels, le), s.buckets[i])
+		}
+		fmt.Fprintf(bw, "%s_bucket%s %d\n", name, join(s.labels, `le="+Inf"`), s.count)
+		fmt.Fprintf(bw, "%s_sum%s %s\n", name, join(s.labels, ""),
+			strconv.FormatFloat(s.sum, 'g', -1, 64),
+		)
+		fmt.Fprintf(bw, "%s_count%s %d\n", name, join(s.labels, ""), s.count)
+	}
+	name = c.name("active")
+	fmt.Fprintf(bw, "# HELP %s Number of armed deadlines.\n", name)
+	fmt.Fprintf(bw, "# TYPE %s gauge\n", name)
+	for _, s := range all {
+		fmt.Fprintf(bw, "%s%s %d\n", name, join(s.labels, ""), s.active)
+	}
+	err := bw.Flush()
+	return cw.n, err
+}
+
+func (c *Exporter) name(metric string) string {
+	if c.Namespace == "" {
+		return "deadline_" + metric
+	}
+	return c.Namespace + "_deadline_" + metric
+}
+
+func formatLabels(labels []string) string {
+	pairs := make([]string, 0, len(labels)/2)
+	for i := 0; i < len(labels); i += 2 {
+		pairs = append(pairs, labels[i]+`="`+escaper.Replace(labels[i+1])+`"`)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+var escaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func join(labels, extra string) string {
+	switch {
+	case labels == "" && extra == "":
+		return ""
+	case labels == "":
+		return "{" + extra + "}"
+	case extra == "":
+		return "{" + labels + "}"
+	default:
+		return "{" + labels + "," + extra + "}"
+	}
+}
+
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
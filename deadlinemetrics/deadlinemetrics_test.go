@@ -0,0 +1,64 @@
+package deadlinemetrics
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gobwas/deadline"
+)
+
+func TestExporter(t *testing.T) {
+	c := Exporter{
+		Namespace: "app",
+		Buckets:   []float64{0.5, 1},
+	}
+	d := deadline.Deadline{
+		Observer: c.Observer("op", "read"),
+	}
+	d.Set(time.Now().Add(time.Hour))
+	d.Do(func() {})
+
+	var buf bytes.Buffer
+	if _, err := c.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		`app_deadline_active{op="read"} 1`,
+		`app_deadline_completion_seconds_bucket{op="read",le="0.5"} 1`,
+		`app_deadline_completion_seconds_count{op="read"} 1`,
+	} {
+		if !strings.Contains(buf.String(), line+"\n") {
+			t.Errorf("no %q line in output:\n%s", line, buf.String())
+		}
+	}
+
+	d.Cancel(nil)
+	buf.Reset()
+	c.WriteTo(&buf)
+	for _, line := range []string{
+		`app_deadline_active{op="read"} 0`,
+		`app_deadline_expirations_total{op="read",reason="cancel"} 1`,
+		`app_deadline_expirations_total{op="read",reason="expire"} 0`,
+	} {
+		if !strings.Contains(buf.String(), line+"\n") {
+			t.Errorf("no %q line in output:\n%s", line, buf.String())
+		}
+	}
+}
+
+func TestExporterActive(t *testing.T) {
+	var c Exporter
+	d := deadline.Deadline{
+		Observer: c.Observer(),
+	}
+	d.Set(time.Now().Add(time.Hour))
+	d.Stop()
+
+	var buf bytes.Buffer
+	c.WriteTo(&buf)
+	if line := "deadline_active 0\n"; !strings.Contains(buf.String(), line) {
+		t.Errorf("no %q line in output:\n%s", line, buf.String())
+	}
+}
@@ -0,0 +1,179 @@
+// Package deadlinetest provides utilities for testing code which uses
+// deadlines without real sleeps.
+package deadlinetest
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gobwas/deadline"
+)
+
+// Clock is a fake deadline.Clock which time is moved manually by Advance()
+// and SetTime() calls. Timers which become due are fired synchronously by
+// those calls in order of their expiration.
+//
+// Timers scheduled with non-positive duration are fired immediately in a
+// separate goroutine, as time.AfterFunc() does.
+type Clock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*timer
+}
+
+// NewClock returns new Clock which current time is t.
+func NewClock(t time.Time) *Clock {
+	return &Clock{now: t}
+}
+
+// Now returns current time of the clock.
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// AfterFunc implements deadline.Clock.
+func (c *Clock) AfterFunc(d time.Duration, f func()) deadline.Timer {
+	t := &timer{c: c, fn: f}
+	t.Reset(d)
+	return t
+}
+
+// NewTimer implements deadline.Clock.
+func (c *Clock) NewTimer(d time.Duration) deadline.Timer {
+	t := &timer{c: c, ch: make(chan time.Time, 1)}
+	t.Reset(d)
+	return t
+}
+
+// Advance moves the clock forward by d, firing all timers which become due.
+func (c *Clock) Advance(d time.Duration) {
+	c.SetTime(c.Now().Add(d))
+}
+
+// SetTime sets current time of the clock to t, firing all timers which become
+// due. Time can not be moved backwards; in that case SetTime() panics.
+func (c *Clock) SetTime(t time.Time) {
+	for {
+		c.mu.Lock()
+		if t.Before(c.now) {
+			c.mu.Unlock()
+			panic("deadlinetest: moving clock backwards")
+		}
+		next := c.next(t)
+		if next == nil {
+			c.now = t
+			c.mu.Unlock()
+			return
+		}
+		if next.at.After(c.now) {
+			c.now = next.at
+		}
+		c.remove(next)
+		now := c.now
+		c.mu.Unlock()
+
+		next.fire(now)
+	}
+}
+
+// Pending returns the number of scheduled timers.
+func (c *Clock) Pending() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.timers)
+}
+
+// next returns earliest timer which is due at t. It must be called with c.mu
+// held.
+func (c *Clock) next(t time.Time) *timer {
+	var first *timer
+	for _, x := range c.timers {
+		if !x.at.After(t) && (first == nil || x.at.Before(first.at)) {
+			first = x
+		}
+	}
+	return first
+}
+
+// remove removes t from scheduled timers. It returns false if t was not
+// scheduled. It must be called with c.mu held.
+func (c *Clock) remove(t *timer) bool {
+	for i, x := range c.timers {
+		if x == t {
+			c.timers = append(c.timers[:i], c.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+type timer struct {
+	c  *Clock
+	at time.Time
+	fn func()
+	ch chan time.Time
+}
+
+func (t *timer) Chan() <-chan time.Time {
+	return t.ch
+}
+
+func (t *timer) Stop() bool {
+	t.c.mu.Lock()
+	defer t.c.mu.Unlock()
+	return t.c.remove(t)
+}
+
+func (t *timer) Reset(d time.Duration) bool {
+	c := t.c
+	c.mu.Lock()
+	active := c.remove(t)
+	if d <= 0 {
+		now := c.now
+		c.mu.Unlock()
+		if t.fn != nil {
+			go t.fn()
+		} else {
+			t.fire(now)
+		}
+		return active
+	}
+	t.at = c.now.Add(d)
+	c.timers = append(c.timers, t)
+	c.mu.Unlock()
+	return active
+}
+
+func (t *timer) fire(now time.Time) {
+	if t.fn != nil {
+		t.fn()
+		return
+	}
+	select {
+	case t.ch <- now:
+	default:
+	}
+}
+
+// AssertExpired reports test error if d is not expired.
+func AssertExpired(tb testing.TB, d *deadline.Deadline) {
+	tb.Helper()
+	select {
+	case <-d.Done():
+	default:
+		tb.Errorf("deadline is not expired")
+	}
+}
+
+// AssertNotExpired reports test error if d is expired.
+func AssertNotExpired(tb testing.TB, d *deadline.Deadline) {
+	tb.Helper()
+	select {
+	case <-d.Done():
+		tb.Errorf("deadline is expired")
+	default:
+	}
+}
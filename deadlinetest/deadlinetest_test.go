@@ -0,0 +1,218 @@
+package deadlinetest
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/gobwas/deadline"
+)
+
+func TestClock(t *testing.T) {
+	c := NewClock(time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC))
+	d := deadline.Deadline{Clock: c}
+	d.SetTimeout(time.Minute)
+
+	c.Advance(59 * time.Second)
+	AssertNotExpired(t, &d)
+
+	c.Advance(time.Second)
+	AssertExpired(t, &d)
+
+	d.SetTimeout(time.Minute)
+	AssertNotExpired(t, &d)
+	d.Stop()
+	c.Advance(time.Hour)
+	AssertNotExpired(t, &d)
+	if n := c.Pending(); n != 0 {
+		t.Errorf("unexpected pending timers: %d", n)
+	}
+}
+
+func TestClockTimer(t *testing.T) {
+	c := NewClock(time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC))
+	tm := c.NewTimer(time.Second)
+	c.Advance(time.Second)
+	select {
+	case now := <-tm.Chan():
+		if !now.Equal(c.Now()) {
+			t.Errorf("unexpected timer time: %s", now)
+		}
+	default:
+		t.Errorf("timer is not fired")
+	}
+}
+
+func TestClockManager(t *testing.T) {
+	c := NewClock(time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC))
+	m := deadline.Manager{Clock: c}
+	d := deadline.Deadline{Manager: &m}
+	d.SetTimeout(time.Minute)
+
+	time.Sleep(10 * time.Millisecond)
+	AssertNotExpired(t, &d)
+	c.Advance(59 * time.Second)
+	AssertNotExpired(t, &d)
+
+	c.Advance(time.Second)
+	AssertExpired(t, &d)
+}
+
+func TestClockTicker(t *testing.T) {
+	c := NewClock(time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC))
+	d := deadline.Deadline{Clock: c}
+	d.SetTimeout(time.Minute)
+	tk := deadline.NewTicker(&d, time.Second)
+	defer tk.Stop()
+
+	select {
+	case <-tk.C:
+		t.Fatalf("tick is made by real time")
+	case <-time.After(20 * time.Millisecond):
+	}
+	c.Advance(time.Second)
+	select {
+	case now := <-tk.C:
+		if exp := c.Now(); !now.Equal(exp) {
+			t.Errorf("unexpected tick time: %s; want %s", now, exp)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("no tick after clock advance")
+	}
+	c.Advance(time.Minute)
+	for now := range tk.C {
+		// Channel must be closed after expiration. Tick which is made right
+		// at the expiration is not delivered.
+		if !now.Before(d.Expires()) {
+			t.Errorf("tick delivered after deadline expiration: %s", now)
+		}
+	}
+}
+
+func TestClockWriter(t *testing.T) {
+	c := NewClock(time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC))
+	d := deadline.Deadline{Clock: c}
+	d.SetTimeout(time.Minute)
+
+	var buf bytes.Buffer
+	w := deadline.NewWriter(&buf, &d, time.Second)
+	w.Write([]byte("a"))
+	d.SetTimeout(10 * time.Second)
+	w.Write([]byte("b"))
+
+	c.Advance(8 * time.Second)
+	if buf.Len() != 0 {
+		t.Fatalf("data flushed too early")
+	}
+	c.Advance(time.Second)
+	if act := buf.String(); act != "ab" {
+		t.Errorf("flushed %q before moved deadline; want %q", act, "ab")
+	}
+}
+
+func TestClockChild(t *testing.T) {
+	c := NewClock(time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC))
+	p := deadline.Deadline{Clock: c}
+	p.SetTimeout(time.Hour)
+
+	var (
+		child = deadline.NewChild(&p)
+		frac  = p.Fraction(0.5)
+		split = p.Split(2)
+		group = deadline.NewGroup(&p).Deadline()
+	)
+	for _, d := range []*deadline.Deadline{child, frac, split[0], split[1], group} {
+		AssertNotExpired(t, d)
+	}
+	c.Advance(30 * time.Minute)
+	AssertExpired(t, frac)
+	AssertExpired(t, split[0])
+	AssertNotExpired(t, child)
+	AssertNotExpired(t, split[1])
+	AssertNotExpired(t, group)
+
+	c.Advance(30 * time.Minute)
+	AssertExpired(t, child)
+	AssertExpired(t, split[1])
+	AssertExpired(t, group)
+}
+
+func TestClockForDownstream(t *testing.T) {
+	c := NewClock(time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC))
+	p := deadline.Deadline{Clock: c}
+	p.SetTimeout(time.Hour)
+
+	down := p.ForDownstream(time.Minute)
+	inner, release := p.Reserve(10 * time.Minute)
+	AssertNotExpired(t, down)
+	AssertNotExpired(t, inner)
+
+	c.Advance(50 * time.Minute)
+	AssertExpired(t, inner)
+	AssertNotExpired(t, down)
+	release()
+	AssertNotExpired(t, inner)
+
+	c.Advance(9 * time.Minute)
+	AssertExpired(t, down)
+	AssertNotExpired(t, inner)
+	c.Advance(time.Minute)
+	AssertExpired(t, inner)
+}
+
+func TestClockEarliest(t *testing.T) {
+	c := NewClock(time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC))
+	var (
+		a = deadline.Deadline{Clock: c}
+		b = deadline.Deadline{Clock: c}
+	)
+	a.SetTimeout(time.Hour)
+	b.SetTimeout(time.Minute)
+
+	e := deadline.Earliest(&a, &b)
+	defer deadline.Release(e)
+	AssertNotExpired(t, e)
+	c.Advance(time.Minute)
+	AssertExpired(t, e)
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("no panic on mixed clocks")
+		}
+	}()
+	deadline.Earliest(&a, new(deadline.Deadline))
+}
+
+func TestClockEvery(t *testing.T) {
+	c := NewClock(time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC))
+	d := deadline.Deadline{Clock: c}
+	d.SetTimeout(time.Minute)
+
+	// waitSleep waits for Every() to sleep till the next tick.
+	waitSleep := func() {
+		for c.Pending() < 2 {
+			time.Sleep(time.Millisecond)
+		}
+	}
+	var (
+		called = make(chan struct{})
+		stats  = make(chan deadline.EveryStats)
+	)
+	go func() {
+		stats <- deadline.Every(&d, time.Second, deadline.Skip, func() {
+			// Make the call to run over the next two ticks.
+			c.Advance(2500 * time.Millisecond)
+			close(called)
+		})
+	}()
+	waitSleep()
+	c.Advance(time.Second)
+	<-called
+	waitSleep()
+	d.Cancel(nil)
+
+	s := <-stats
+	if s.Calls != 1 || s.Missed != 2 {
+		t.Errorf("unexpected stats: %+v; want 1 call and 2 missed ticks", s)
+	}
+}
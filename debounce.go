@@ -0,0 +1,106 @@
+package deadline
+
+import (
+	"sync"
+	"time"
+)
+
+// Debouncer coalesces events and calls a function once events stop arriving
+// for a quiet period, but no later than the max wait since the first of the
+// coalesced events. That is, function is called even if events keep
+// arriving.
+type Debouncer struct {
+	fn      func()
+	quiet   time.Duration
+	maxWait time.Duration
+
+	mu      sync.Mutex
+	pending bool
+	first   time.Time
+	last    time.Time
+	timer   *time.Timer
+	max     Deadline
+}
+
+// NewDebouncer creates new Debouncer calling fn after quiet period without
+// events, but no later than maxWait since the first event.
+func NewDebouncer(quiet, maxWait time.Duration, fn func()) *Debouncer {
+	b := &Debouncer{
+		fn:      fn,
+		quiet:   quiet,
+		maxWait: maxWait,
+	}
+	b.max.OnExpire(b.onMaxWait)
+	return b
+}
+
+// NewThrottler creates new Debouncer calling fn at most once per interval:
+// fn is called after the interval since the first of the coalesced events.
+func NewThrottler(interval time.Duration, fn func()) *Debouncer {
+	return NewDebouncer(interval, interval, fn)
+}
+
+// Trigger registers an event.
+func (b *Debouncer) Trigger() {
+	now := time.Now()
+	b.mu.Lock()
+	first := !b.pending
+	if first {
+		b.pending = true
+		b.first = now
+	}
+	b.last = now
+	if b.timer == nil {
+		b.timer = time.AfterFunc(b.quiet, b.onQuiet)
+	} else {
+		b.timer.Reset(b.quiet)
+	}
+	b.mu.Unlock()
+
+	if first {
+		// NOTE: we call Set() without b.mu held because it may call hooks
+		// synchronously.
+		b.max.Set(now.Add(b.maxWait))
+	}
+}
+
+// Flush calls function immediately if there are coalesced events.
+func (b *Debouncer) Flush() {
+	b.mu.Lock()
+	b.flush()
+}
+
+func (b *Debouncer) onQuiet() {
+	b.mu.Lock()
+	if time.Since(b.last) < b.quiet {
+		// Stale timer, which was reset by the recent Trigger() call.
+		b.mu.Unlock()
+		return
+	}
+	b.flush()
+}
+
+func (b *Debouncer) onMaxWait() {
+	b.mu.Lock()
+	if time.Since(b.first) < b.maxWait {
+		// Stale expiration of the previous events batch.
+		b.mu.Unlock()
+		return
+	}
+	b.flush()
+}
+
+// flush must be called with b.mu held. It unlocks b.mu before calling the
+// function.
+func (b *Debouncer) flush() {
+	if !b.pending {
+		b.mu.Unlock()
+		return
+	}
+	b.pending = false
+	b.timer.Stop()
+	b.max.Stop()
+	b.mu.Unlock()
+
+	b.fn()
+}
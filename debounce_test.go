@@ -0,0 +1,42 @@
+package deadline
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDebouncerMaxWait(t *testing.T) {
+	var n int32
+	b := NewDebouncer(20*time.Millisecond, 50*time.Millisecond, func() {
+		atomic.AddInt32(&n, 1)
+	})
+	// Events keep arriving more frequently than the quiet period.
+	for end := time.Now().Add(80 * time.Millisecond); time.Now().Before(end); {
+		b.Trigger()
+		time.Sleep(5 * time.Millisecond)
+	}
+	if atomic.LoadInt32(&n) == 0 {
+		t.Errorf("function was not called after max wait")
+	}
+	b.Flush()
+	c := atomic.LoadInt32(&n)
+	time.Sleep(50 * time.Millisecond)
+	if atomic.LoadInt32(&n) != c {
+		t.Errorf("function was called after flush without events")
+	}
+}
+
+func TestDebouncerQuiet(t *testing.T) {
+	called := make(chan struct{}, 1)
+	b := NewDebouncer(10*time.Millisecond, time.Hour, func() {
+		called <- struct{}{}
+	})
+	b.Trigger()
+	b.Trigger()
+	select {
+	case <-called:
+	case <-time.After(time.Second):
+		t.Fatalf("function was not called after quiet period")
+	}
+}
@@ -0,0 +1,102 @@
+package deadline
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+)
+
+// SelfWaitError is reported to the Deadline.Debug when Do() callback makes
+// blocking call of Do(), ClearAndWait() or ExpireAndWait() of the same
+// Deadline it is running under. Such callback will most likely block until
+// deadline expiration, making Do() to always return ErrDeadline. Note that
+// cooperative checks of Done() are not reported.
+type SelfWaitError struct {
+	// Name is the name of the deadline, if any.
+	Name string
+
+	// Method is the name of the method called from the callback.
+	Method string
+
+	// Stack is the stack of the Do() caller goroutine.
+	Stack []byte
+
+	// CallbackStack is the stack of the callback goroutine at the moment
+	// of Method call.
+	CallbackStack []byte
+}
+
+func (e *SelfWaitError) Error() string {
+	var name string
+	if e.Name != "" {
+		name = " " + strconv.Quote(e.Name)
+	}
+	return fmt.Sprintf(
+		"deadline: %s() called from Do() callback of the same deadline%s\n"+
+			"\nDo() caller stack:\n%s\ncallback stack:\n%s",
+		e.Method, name, e.Stack, e.CallbackStack,
+	)
+}
+
+// checkSelfWait reports SelfWaitError to d.Debug if current goroutine is
+// running some Do() callback of d.
+func (d *Deadline) checkSelfWait(method string) {
+	id := goid()
+	if id == 0 {
+		return
+	}
+	d.mu.Lock()
+	caller, ok := d.calls[id]
+	d.mu.Unlock()
+	if ok {
+		d.Debug(&SelfWaitError{
+			Name:          d.Name,
+			Method:        method,
+			Stack:         caller,
+			CallbackStack: stack(false),
+		})
+	}
+}
+
+// trackCall wraps given Do() callback such that it becomes known by
+// checkSelfWait().
+func (d *Deadline) trackCall(cb func()) func() {
+	caller := stack(false)
+	return func() {
+		id := goid()
+		if id == 0 {
+			cb()
+			return
+		}
+		d.mu.Lock()
+		if d.calls == nil {
+			d.calls = make(map[uint64][]byte)
+		}
+		d.calls[id] = caller
+		d.mu.Unlock()
+		defer func() {
+			d.mu.Lock()
+			delete(d.calls, id)
+			d.mu.Unlock()
+		}()
+		cb()
+	}
+}
+
+// goroutineStack returns stack of the goroutine with given id or nil if there
+// is no such goroutine.
+func goroutineStack(id uint64) []byte {
+	return findStack(stack(true), id)
+}
+
+// findStack returns stack of the goroutine with given id from the stacks of
+// all goroutines.
+func findStack(stacks []byte, id uint64) []byte {
+	header := []byte("goroutine " + strconv.FormatUint(id, 10) + " [")
+	for _, g := range bytes.Split(stacks, []byte("\n\n")) {
+		if bytes.HasPrefix(g, header) {
+			return g
+		}
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+//go:build !tinygo
+
+package deadline
+
+import (
+	"bytes"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestDeadlineDebugSelfWait(t *testing.T) {
+	reports := make(chan error, 1)
+	d := Deadline{
+		Debug: func(err error) {
+			reports <- err
+		},
+	}
+	d.Set(time.Now().Add(10 * time.Millisecond))
+	err := d.Do(func() {
+		select {
+		case <-d.Done():
+			t.Errorf("deadline expired before nested Do()")
+		default:
+		}
+		d.Do(func() {
+			<-d.Done()
+		})
+	})
+	if err != ErrDeadline {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	select {
+	case err := <-reports:
+		e, ok := err.(*SelfWaitError)
+		if !ok {
+			t.Fatalf("unexpected report: %#v", err)
+		}
+		if e.Method != "Do" {
+			t.Errorf("unexpected method: %q", e.Method)
+		}
+		if len(e.Stack) == 0 || len(e.CallbackStack) == 0 {
+			t.Errorf("empty stacks reported")
+		}
+	default:
+		t.Fatalf("self wait was not reported")
+	}
+}
+
+func TestDeadlineCaptureStack(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		rate    int
+		capture bool
+	}{
+		{
+			name:    "every",
+			capture: true,
+		},
+		{
+			name:    "sampled",
+			rate:    math.MaxInt32,
+			capture: false,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			d := Deadline{
+				CaptureStack:     true,
+				CaptureStackRate: test.rate,
+			}
+			d.Set(time.Now().Add(10 * time.Millisecond))
+			release := make(chan struct{})
+			defer close(release)
+			err := d.Do(func() {
+				slowOperation(release)
+			})
+			e, ok := err.(*ExpireError)
+			if !ok {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			act := bytes.Contains(e.Stack, []byte("slowOperation"))
+			if act != test.capture {
+				t.Errorf(
+					"stack captured: %t; want %t:\n%s",
+					act, test.capture, e.Stack,
+				)
+			}
+		})
+	}
+}
+
+//go:noinline
+func slowOperation(release <-chan struct{}) {
+	<-release
+}
@@ -0,0 +1,26 @@
+package deadline
+
+import "time"
+
+// DoDetach runs cb in a separate goroutine (started by Goer, if any) and
+// returns immediately. When cb returns, onDone is called from the callback
+// goroutine with the callback duration and whether the deadline expired
+// before the callback returned.
+//
+// It is intended for background tasks which need deadline accounting but not
+// the synchronous wait of Do().
+func (d *Deadline) DoDetach(cb func(), onDone func(timedOut bool, took time.Duration)) {
+	done := d.doneChan()
+	goer(d.Goer, done, func() {
+		start := time.Now()
+		cb()
+		took := time.Since(start)
+		var timedOut bool
+		select {
+		case <-done:
+			timedOut = true
+		default:
+		}
+		onDone(timedOut, took)
+	})
+}
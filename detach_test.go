@@ -0,0 +1,31 @@
+package deadline
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDeadlineDoDetach(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		delay    time.Duration
+		timedOut bool
+	}{
+		{"in time", 0, false},
+		{"timed out", 50 * time.Millisecond, true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var d Deadline
+			d.Set(time.Now().Add(20 * time.Millisecond))
+			done := make(chan bool, 1)
+			d.DoDetach(func() {
+				time.Sleep(test.delay)
+			}, func(timedOut bool, _ time.Duration) {
+				done <- timedOut
+			})
+			if act := <-done; act != test.timedOut {
+				t.Errorf("timedOut = %t; want %t", act, test.timedOut)
+			}
+		})
+	}
+}
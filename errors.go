@@ -0,0 +1,175 @@
+package deadline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// ExpireError is returned by Do() instead of ErrDeadline when there is an
+// additional information about the expiration. ExpireError unwraps to the
+// ErrDeadline (or to the error given by WithError()), so errors.Is(err,
+// ErrDeadline) reports true for it.
+type ExpireError struct {
+	// Stack contains the stack of the callback goroutine at the moment of
+	// expiration. It is captured only when Deadline.CaptureStack is true and
+	// the callback goroutine was started before the expiration.
+	Stack []byte
+
+	// Phases and Elapsed contain time breakdown of the attached Stopwatch at
+	// the moment of expiration.
+	Phases  []Phase
+	Elapsed time.Duration
+
+	// Label is the operation name of the call (see WithName() and Deadline's
+	// Name). It is empty if operation is not named.
+	Label string
+
+	// Deadline is the deadline point which was exceeded.
+	Deadline time.Time
+
+	// Start is the time when Do() was called.
+	Start time.Time
+
+	// Wait is the duration between the Do() call and the expiration.
+	Wait time.Duration
+
+	err  error
+	call *call
+}
+
+// MissedBy returns duration between the deadline and the callback return. If
+// callback is still running (or was not even started), it returns duration
+// between the deadline and now. That is, it helps to distinguish slightly
+// late callbacks from the stuck ones.
+func (e *ExpireError) MissedBy() time.Duration {
+	if e.Deadline.IsZero() {
+		return 0
+	}
+	if took := atomic.LoadInt64(&e.call.took); took != 0 {
+		return e.call.start.Add(time.Duration(took)).Sub(e.Deadline)
+	}
+	return time.Since(e.Deadline)
+}
+
+func (e *ExpireError) Error() string {
+	var info []string
+	if !e.Start.IsZero() {
+		info = append(info, "waited "+e.Wait.String())
+	}
+	if e.Elapsed != 0 {
+		info = append(info, formatPhases(e.Phases, e.Elapsed))
+	}
+	msg := e.err.Error()
+	if e.Label != "" {
+		msg = e.Label + ": " + msg
+	}
+	if len(info) == 0 {
+		return msg
+	}
+	return msg + " (" + strings.Join(info, "; ") + ")"
+}
+
+func (e *ExpireError) Unwrap() error { return e.err }
+
+// Timeout reports whether e means the deadline was exceeded. It is false
+// when the deadline was canceled (see Cancel()).
+func (e *ExpireError) Timeout() bool   { return errors.Is(e.err, ErrDeadline) }
+func (e *ExpireError) Temporary() bool { return errors.Is(e.err, ErrDeadline) }
+
+// ExhaustedError is returned by DoEarliest() when one of the deadlines
+// expires. It unwraps to the ErrDeadline.
+type ExhaustedError struct {
+	// Deadline is the deadline which expired.
+	Deadline *Deadline
+}
+
+func (e *ExhaustedError) Error() string {
+	if e.Deadline.Name == "" {
+		return ErrDeadline.Error()
+	}
+	return fmt.Sprintf("%s: %s", e.Deadline.Name, ErrDeadline.Error())
+}
+
+func (e *ExhaustedError) Unwrap() error   { return ErrDeadline }
+func (e *ExhaustedError) Timeout() bool   { return true }
+func (e *ExhaustedError) Temporary() bool { return true }
+
+// InterruptError is returned by DoInterrupt() when the deadline expires and
+// interruption of the callback fails. It unwraps to the ErrDeadline.
+type InterruptError struct {
+	// Err is an error returned by the interrupt function.
+	Err error
+}
+
+func (e *InterruptError) Error() string {
+	return ErrDeadline.Error() + "; interrupt failed: " + e.Err.Error()
+}
+
+func (e *InterruptError) Unwrap() error   { return ErrDeadline }
+func (e *InterruptError) Timeout() bool   { return true }
+func (e *InterruptError) Temporary() bool { return true }
+
+// PanicMode defines how Do() handles panics of the callback.
+type PanicMode uint8
+
+const (
+	// PanicDefault means that panic mode is inherited from the Deadline.
+	// It is the same as PanicCrash for the Deadline's Panics field.
+	PanicDefault PanicMode = iota
+
+	// PanicCrash leaves panics unrecovered, which crashes the process from
+	// the callback goroutine.
+	PanicCrash
+
+	// PanicReturn makes Do() to recover panic and return it as *PanicError.
+	PanicReturn
+
+	// PanicRepanic makes Do() to recover panic and panic again with
+	// *PanicError value in the goroutine which called Do().
+	PanicRepanic
+)
+
+// PanicError is returned by Do() when the callback panics and panic mode is
+// PanicReturn. It is also the value of the panic re-raised in PanicRepanic
+// mode.
+type PanicError struct {
+	// Value is the value passed to panic().
+	Value interface{}
+
+	// Stack is the stack of the panicked callback.
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("deadline: callback panicked: %v", e.Value)
+}
+
+// IsTimeout reports whether err means that some deadline exceeded. It
+// recognizes ErrDeadline, context.DeadlineExceeded, os.ErrDeadlineExceeded
+// and errors having Timeout() method reporting true, such as net.Error. Error
+// chains are inspected as errors.Is() does.
+func IsTimeout(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, ErrDeadline) ||
+		errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, os.ErrDeadlineExceeded) {
+		return true
+	}
+	var t interface{ Timeout() bool }
+	return errors.As(err, &t) && t.Timeout()
+}
+
+// IsCanceled reports whether err means that operation was canceled, such as
+// ErrCanceled or context.Canceled do. Error chains are inspected as
+// errors.Is() does.
+func IsCanceled(err error) bool {
+	return err != nil && (errors.Is(err, ErrCanceled) ||
+		errors.Is(err, context.Canceled))
+}
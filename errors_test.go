@@ -0,0 +1,136 @@
+package deadline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExpireErrorMissedBy(t *testing.T) {
+	d := Deadline{
+		RichErrors: true,
+	}
+	d.Set(time.Now().Add(10 * time.Millisecond))
+	release := make(chan struct{})
+	err := d.Do(func() {
+		<-release
+	})
+	e, ok := err.(*ExpireError)
+	if !ok {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if m := e.MissedBy(); m < 20*time.Millisecond {
+		t.Errorf("unexpected missed duration of running callback: %s", m)
+	}
+	close(release)
+	time.Sleep(10 * time.Millisecond) // Let the callback goroutine exit.
+	m1 := e.MissedBy()
+	time.Sleep(10 * time.Millisecond)
+	if m2 := e.MissedBy(); m1 != m2 {
+		t.Errorf("missed duration changed after callback return: %s -> %s", m1, m2)
+	}
+}
+
+func TestExpireErrorMetadata(t *testing.T) {
+	d := Deadline{
+		RichErrors: true,
+	}
+	at := time.Now().Add(10 * time.Millisecond)
+	d.Set(at)
+	err := d.Do(func() {
+		time.Sleep(50 * time.Millisecond)
+	}, WithName("auth"))
+	e, ok := err.(*ExpireError)
+	if !ok {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if e.Label != "auth" {
+		t.Errorf("unexpected label: %q", e.Label)
+	}
+	if !e.Deadline.Equal(at) {
+		t.Errorf("unexpected deadline: %s; want %s", e.Deadline, at)
+	}
+	if e.Start.IsZero() || e.Wait <= 0 || e.Wait > 50*time.Millisecond {
+		t.Errorf("unexpected timings: start=%s wait=%s", e.Start, e.Wait)
+	}
+	if s := e.Error(); !strings.HasPrefix(s, "auth: deadline exceeded (waited ") {
+		t.Errorf("unexpected error text: %q", s)
+	}
+}
+
+func TestExpireErrorCanceled(t *testing.T) {
+	d := Deadline{
+		Name: "op",
+	}
+	d.Set(time.Now().Add(time.Hour))
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		d.Cancel(nil)
+	}()
+	release := make(chan struct{})
+	defer close(release)
+	err := d.Do(func() {
+		<-release
+	})
+	if _, ok := err.(*ExpireError); !ok {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if IsTimeout(err) {
+		t.Errorf("IsTimeout(%v) = true; want false", err)
+	}
+	if !IsCanceled(err) {
+		t.Errorf("IsCanceled(%v) = false; want true", err)
+	}
+}
+
+func TestErrDeadlineIs(t *testing.T) {
+	for _, target := range []error{
+		ErrDeadline,
+		context.DeadlineExceeded,
+		os.ErrDeadlineExceeded,
+	} {
+		if !errors.Is(ErrDeadline, target) {
+			t.Errorf("ErrDeadline does not match %v", target)
+		}
+		if !errors.Is(&ExpireError{err: ErrDeadline}, target) {
+			t.Errorf("*ExpireError does not match %v", target)
+		}
+	}
+	if errors.Is(ErrDeadline, context.Canceled) {
+		t.Errorf("ErrDeadline matches context.Canceled")
+	}
+}
+
+func TestIsTimeout(t *testing.T) {
+	for _, test := range []struct {
+		err      error
+		timeout  bool
+		canceled bool
+	}{
+		{nil, false, false},
+		{ErrDeadline, true, false},
+		{&ExpireError{err: ErrDeadline}, true, false},
+		{&ExpireError{err: ErrCanceled}, false, true},
+		{fmt.Errorf("read: %w", os.ErrDeadlineExceeded), true, false},
+		{context.DeadlineExceeded, true, false},
+		{&net.OpError{Op: "read", Err: os.ErrDeadlineExceeded}, true, false},
+		{fmt.Errorf("call: %w", context.Canceled), false, true},
+		{ErrCanceled, false, true},
+		{ErrRejected, true, false},
+		{io.EOF, false, false},
+	} {
+		if act := IsTimeout(test.err); act != test.timeout {
+			t.Errorf("IsTimeout(%v) = %t; want %t", test.err, act, test.timeout)
+		}
+		if act := IsCanceled(test.err); act != test.canceled {
+			t.Errorf("IsCanceled(%v) = %t; want %t", test.err, act, test.canceled)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package deadline
+
+import "time"
+
+// EventType describes the kind of the deadline lifecycle event.
+type EventType uint8
+
+const (
+	// EventSet is emitted when deadline is set up by Set() or disarmed by
+	// Stop(), Clear(), ClearAndWait() or Release(). In latter case Event's At
+	// is zero.
+	EventSet EventType = iota + 1
+
+	// EventExpire is emitted when deadline expires.
+	EventExpire
+
+	// EventCancel is emitted when deadline is expired early by Cancel() or
+	// other expiration with explicit reason, such as ExpireTagged().
+	EventCancel
+
+	// EventComplete is emitted when Do() callback returns before the
+	// deadline expiration.
+	EventComplete
+)
+
+func (t EventType) String() string {
+	switch t {
+	case EventSet:
+		return "set"
+	case EventExpire:
+		return "expire"
+	case EventCancel:
+		return "cancel"
+	case EventComplete:
+		return "complete"
+	default:
+		return "unknown"
+	}
+}
+
+// Event describes single transition of the deadline.
+type Event struct {
+	Type     EventType
+	Deadline *Deadline
+
+	// Time is the time when event happened.
+	Time time.Time
+
+	// At is the deadline point. It is zero for EventSet emitted when the
+	// deadline is disarmed.
+	At time.Time
+
+	// Name is the operation name of the completed call. It is set only for
+	// EventComplete.
+	Name string
+
+	// Took is the duration of the completed call. It is set only for
+	// EventComplete.
+	Took time.Duration
+
+	// Err is the cancellation reason for EventCancel, or the *PanicError
+	// for EventComplete of the panicked call.
+	Err error
+}
+
+// emit passes e to d.Observer, if any, and accounts it in d statistics if
+// they are collected.
+func (d *Deadline) emit(e Event) {
+	if !d.observed() {
+		return
+	}
+	if d.CollectStats {
+		d.mu.Lock()
+		d.stats.record(&e)
+		d.mu.Unlock()
+	}
+	if d.Observer != nil {
+		e.Deadline = d
+		e.Time = d.now()
+		d.Observer(e)
+	}
+}
+
+// observed reports whether d's events are consumed.
+func (d *Deadline) observed() bool {
+	return d.Observer != nil || d.CollectStats
+}
+
+// emitExpire emits expiration event for the deadline point at expired with
+// the given cause.
+func (d *Deadline) emitExpire(at time.Time, cause error) {
+	if cause != nil {
+		d.emit(Event{Type: EventCancel, At: at, Err: cause})
+	} else {
+		d.emit(Event{Type: EventExpire, At: at})
+	}
+}
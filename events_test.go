@@ -0,0 +1,70 @@
+package deadline
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDeadlineObserver(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		events []EventType
+	)
+	d := Deadline{
+		Observer: func(e Event) {
+			mu.Lock()
+			defer mu.Unlock()
+			events = append(events, e.Type)
+		},
+	}
+	expired := make(chan struct{})
+	d.AfterExpire(func() {
+		close(expired)
+	})
+	d.Set(time.Now().Add(10 * time.Millisecond))
+	d.Do(func() {})
+	<-expired
+	d.Set(time.Now().Add(time.Hour))
+	d.Cancel(nil)
+
+	mu.Lock()
+	defer mu.Unlock()
+	exp := []EventType{
+		EventSet,
+		EventComplete,
+		EventExpire,
+		EventSet,
+		EventCancel,
+	}
+	if !reflect.DeepEqual(events, exp) {
+		t.Errorf("unexpected events: %v; want %v", events, exp)
+	}
+}
+
+func TestDeadlineObserverDisarm(t *testing.T) {
+	var events []Event
+	d := Acquire()
+	d.Observer = func(e Event) {
+		events = append(events, e)
+	}
+	d.Set(time.Now().Add(time.Hour))
+	d.Stop()
+	d.Set(time.Now().Add(time.Hour))
+	d.ClearAndWait()
+	d.Set(time.Now().Add(time.Hour))
+	Release(d)
+
+	if n := len(events); n != 6 {
+		t.Fatalf("unexpected number of events: %d; want 6", n)
+	}
+	for i, e := range events {
+		if e.Type != EventSet {
+			t.Errorf("unexpected event #%d type: %s", i, e.Type)
+		}
+		if disarm := i%2 == 1; disarm != e.At.IsZero() {
+			t.Errorf("unexpected event #%d deadline point: %s", i, e.At)
+		}
+	}
+}
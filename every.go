@@ -0,0 +1,66 @@
+package deadline
+
+import "time"
+
+// MissPolicy describes how Every() handles ticks missed because of the long
+// running function.
+type MissPolicy int
+
+const (
+	// Skip means that missed ticks are skipped and function is called on the
+	// next tick.
+	Skip MissPolicy = iota
+	// Coalesce means that all missed ticks are coalesced into a single call
+	// made immediately.
+	Coalesce
+)
+
+// EveryStats contains statistics of the Every() run.
+type EveryStats struct {
+	// Calls is the number of function calls made.
+	Calls int
+
+	// Missed is the number of ticks missed because of the long running
+	// function.
+	Missed int
+}
+
+// Every calls fn on the fixed cadence of the given interval until the
+// deadline d expires. Calls are aligned to the time Every() was called at, so
+// the duration of fn calls does not accumulate drift. Ticks which were missed
+// due to fn running longer than interval are handled according to the given
+// policy.
+//
+// Every() returns when deadline expires, but note that it does not interrupt
+// the running fn call.
+func Every(d *Deadline, interval time.Duration, policy MissPolicy, fn func()) EveryStats {
+	var (
+		stats EveryStats
+		clock = d.clock()
+		start = clock.Now()
+		now   bool
+	)
+	for k := 1; ; {
+		if now {
+			now = false
+			if isExpired(d) {
+				return stats
+			}
+		} else {
+			next := start.Add(time.Duration(k) * interval)
+			if !sleep(d, next.Sub(clock.Now())) {
+				return stats
+			}
+		}
+		fn()
+		stats.Calls++
+
+		// Compute the number of ticks passed while fn was running.
+		passed := int(clock.Now().Sub(start)/interval) - k
+		if passed > 0 {
+			stats.Missed += passed
+			now = policy == Coalesce
+		}
+		k += passed + 1
+	}
+}
@@ -0,0 +1,38 @@
+package deadline
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEvery(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		policy MissPolicy
+		calls  int
+	}{
+		{"skip", Skip, 2},
+		{"coalesce", Coalesce, 3},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var d Deadline
+			d.Set(time.Now().Add(220 * time.Millisecond))
+			var n int
+			stats := Every(&d, 40*time.Millisecond, test.policy, func() {
+				if n++; n == 1 {
+					// Miss about two ticks.
+					time.Sleep(100 * time.Millisecond)
+				}
+			})
+			if stats.Calls != n {
+				t.Errorf("stats calls is %d; want %d", stats.Calls, n)
+			}
+			if stats.Missed != 2 {
+				t.Errorf("stats missed is %d; want 2", stats.Missed)
+			}
+			if n < test.calls {
+				t.Errorf("fn called %d times; want at least %d", n, test.calls)
+			}
+		})
+	}
+}
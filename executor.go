@@ -0,0 +1,171 @@
+package deadline
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+)
+
+// Executor runs tasks on a fixed number of goroutines picking the task with
+// the earliest deadline first. Tasks which deadline passes while they wait in
+// the queue are dropped instead of being run.
+type Executor struct {
+	// OnDrop is an optional function called when a queued task is dropped
+	// due to its deadline expiration or Executor's Close().
+	OnDrop func()
+
+	mu     sync.Mutex
+	cond   sync.Cond
+	queue  edfQueue
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// NewExecutor creates Executor with given number of worker goroutines.
+func NewExecutor(workers int) *Executor {
+	if workers <= 0 {
+		panic("deadline: non-positive number of workers")
+	}
+	e := new(Executor)
+	e.cond.L = &e.mu
+	e.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go e.worker()
+	}
+	return e
+}
+
+// Submit queues task to be run before at. Task is dropped if at passes or
+// cancel becomes closed while it waits in the queue. Zero at means no
+// deadline; such tasks are run after all tasks with a deadline. It returns
+// false if Executor is closed.
+func (e *Executor) Submit(at time.Time, cancel <-chan struct{}, task func()) bool {
+	return e.submit(&edfTask{
+		at:     at,
+		cancel: cancel,
+		fn:     task,
+	})
+}
+
+func (e *Executor) submit(t *edfTask) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.closed {
+		return false
+	}
+	heap.Push(&e.queue, t)
+	e.cond.Signal()
+	return true
+}
+
+// Do runs cb under d as d.Do() does, but schedules the callback on e by the
+// d's deadline. If Executor drops the callback before the deadline expires,
+// for example, due to Close(), Do() returns ErrRejected.
+func (e *Executor) Do(d *Deadline, cb func(), opts ...Option) error {
+	at := d.Expires()
+	opts = append(opts, WithRejectGoer(func(cancel <-chan struct{}, task, reject func()) {
+		t := &edfTask{
+			at:     at,
+			cancel: cancel,
+			fn:     task,
+			reject: reject,
+		}
+		if !e.submit(t) {
+			e.drop(t)
+		}
+	}))
+	return d.Do(cb, opts...)
+}
+
+// Close stops the workers after they finish running tasks and drops all
+// queued tasks. It waits for the workers to exit.
+func (e *Executor) Close() {
+	e.mu.Lock()
+	e.closed = true
+	dropped := e.queue
+	e.queue = nil
+	e.cond.Broadcast()
+	e.mu.Unlock()
+	for _, t := range dropped {
+		e.drop(t)
+	}
+	e.wg.Wait()
+}
+
+func (e *Executor) worker() {
+	defer e.wg.Done()
+	for {
+		e.mu.Lock()
+		for len(e.queue) == 0 && !e.closed {
+			e.cond.Wait()
+		}
+		if e.closed {
+			e.mu.Unlock()
+			return
+		}
+		t := heap.Pop(&e.queue).(*edfTask)
+		e.mu.Unlock()
+
+		if t.expired() {
+			e.drop(t)
+			continue
+		}
+		t.fn()
+	}
+}
+
+func (e *Executor) drop(t *edfTask) {
+	if t.reject != nil {
+		t.reject()
+	}
+	if e.OnDrop != nil {
+		e.OnDrop()
+	}
+}
+
+type edfTask struct {
+	at     time.Time
+	cancel <-chan struct{}
+	fn     func()
+	reject func()
+}
+
+func (t *edfTask) expired() bool {
+	select {
+	case <-t.cancel:
+		return true
+	default:
+	}
+	return !t.at.IsZero() && !time.Now().Before(t.at)
+}
+
+// before reports whether t must be run before x.
+func (t *edfTask) before(x *edfTask) bool {
+	switch {
+	case t.at.IsZero():
+		return false
+	case x.at.IsZero():
+		return true
+	default:
+		return t.at.Before(x.at)
+	}
+}
+
+type edfQueue []*edfTask
+
+func (q edfQueue) Len() int           { return len(q) }
+func (q edfQueue) Less(i, j int) bool { return q[i].before(q[j]) }
+func (q edfQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+
+func (q *edfQueue) Push(x interface{}) {
+	*q = append(*q, x.(*edfTask))
+}
+
+func (q *edfQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	t := old[n-1]
+	old[n-1] = nil
+	*q = old[:n-1]
+	return t
+}
@@ -0,0 +1,103 @@
+package deadline
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestExecutorEarliestFirst(t *testing.T) {
+	e := NewExecutor(1)
+	defer e.Close()
+
+	var (
+		mu      sync.Mutex
+		order   []int
+		wg      sync.WaitGroup
+		release = make(chan struct{})
+	)
+	// Block the only worker to let the queue fill up.
+	e.Submit(time.Time{}, nil, func() { <-release })
+	time.Sleep(10 * time.Millisecond)
+
+	now := time.Now()
+	for _, i := range []int{3, 1, 2} {
+		i := i
+		wg.Add(1)
+		e.Submit(now.Add(time.Duration(i)*time.Hour), nil, func() {
+			defer wg.Done()
+			mu.Lock()
+			order = append(order, i)
+			mu.Unlock()
+		})
+	}
+	close(release)
+	wg.Wait()
+
+	if exp := []int{1, 2, 3}; !reflect.DeepEqual(order, exp) {
+		t.Errorf("unexpected order: %v; want %v", order, exp)
+	}
+}
+
+func TestExecutorDropExpired(t *testing.T) {
+	e := NewExecutor(1)
+	defer e.Close()
+	dropped := make(chan struct{}, 1)
+	e.OnDrop = func() {
+		dropped <- struct{}{}
+	}
+	release := make(chan struct{})
+	e.Submit(time.Time{}, nil, func() { <-release })
+	time.Sleep(10 * time.Millisecond)
+
+	var d Deadline
+	d.Set(time.Now().Add(10 * time.Millisecond))
+	err := e.Do(&d, func() {
+		t.Errorf("expired task was run")
+	})
+	if err != ErrDeadline {
+		t.Errorf("unexpected error: %v; want %v", err, ErrDeadline)
+	}
+	close(release)
+	select {
+	case <-dropped:
+	case <-time.After(time.Second):
+		t.Errorf("expired task was not dropped")
+	}
+}
+
+func TestExecutorClose(t *testing.T) {
+	e := NewExecutor(1)
+	release := make(chan struct{})
+	e.Submit(time.Time{}, nil, func() { <-release })
+	time.Sleep(10 * time.Millisecond)
+
+	var d Deadline // Not set.
+	errc := make(chan error, 1)
+	go func() {
+		errc <- e.Do(&d, func() {
+			t.Errorf("task of closed executor was run")
+		})
+	}()
+	time.Sleep(10 * time.Millisecond)
+	closed := make(chan struct{})
+	go func() {
+		e.Close()
+		close(closed)
+	}()
+	select {
+	case err := <-errc:
+		if err != ErrRejected {
+			t.Errorf("unexpected error: %v; want %v", err, ErrRejected)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("Do() was not completed by Close()")
+	}
+	close(release)
+	<-closed
+
+	if err := e.Do(&d, func() {}); err != ErrRejected {
+		t.Errorf("unexpected error after Close(): %v; want %v", err, ErrRejected)
+	}
+}
@@ -0,0 +1,106 @@
+package deadline
+
+import (
+	"time"
+)
+
+// GoerMiddleware wraps GoFunc to extend its behaviour.
+type GoerMiddleware func(GoFunc) GoFunc
+
+// ChainGoer returns GoFunc which starts goroutines by g wrapped with given
+// middlewares. The first middleware is the outermost one. If g is nil,
+// goroutines are started by the go statement.
+func ChainGoer(g GoFunc, mws ...GoerMiddleware) GoFunc {
+	if g == nil {
+		g = func(_ <-chan struct{}, task func()) {
+			go task()
+		}
+	}
+	for i := len(mws) - 1; i >= 0; i-- {
+		g = mws[i](g)
+	}
+	return g
+}
+
+// wrapTask returns middleware which wraps tasks by fn.
+func wrapTask(fn func(task func()) func()) GoerMiddleware {
+	return func(g GoFunc) GoFunc {
+		return func(cancel <-chan struct{}, task func()) {
+			g(cancel, fn(task))
+		}
+	}
+}
+
+// RecoverGoer returns middleware which recovers panics of the tasks and
+// passes recovered values to the handler.
+func RecoverGoer(handler func(interface{})) GoerMiddleware {
+	return wrapTask(func(task func()) func() {
+		return func() {
+			defer func() {
+				if v := recover(); v != nil {
+					handler(v)
+				}
+			}()
+			task()
+		}
+	})
+}
+
+// GoerMetrics contains hooks called by the middleware returned by
+// MetricsGoer(). Any of them can be nil.
+type GoerMetrics struct {
+	// Started is called when task is started with the time it was waiting
+	// for the start.
+	Started func(wait time.Duration)
+
+	// Finished is called when task is finished with its duration.
+	Finished func(run time.Duration)
+}
+
+// MetricsGoer returns middleware which reports tasks timings to m.
+func MetricsGoer(m GoerMetrics) GoerMiddleware {
+	return wrapTask(func(task func()) func() {
+		queued := time.Now()
+		return func() {
+			start := time.Now()
+			if m.Started != nil {
+				m.Started(start.Sub(queued))
+			}
+			if m.Finished != nil {
+				defer func() {
+					m.Finished(time.Since(start))
+				}()
+			}
+			task()
+		}
+	})
+}
+
+// LimitGoer returns middleware which limits the number of concurrently
+// running tasks by n. Started task waits until some of the running tasks
+// finishes or the cancelation channel becomes closed; in latter case task is
+// not run.
+//
+// Note that the limit is taken only when the task starts, so tasks dropped by
+// the wrapped GoFunc (e.g. WorkerPool's rejected ones) do not hold it.
+func LimitGoer(n int) GoerMiddleware {
+	sem := make(chan struct{}, n)
+	return func(g GoFunc) GoFunc {
+		return func(cancel <-chan struct{}, task func()) {
+			g(cancel, func() {
+				select {
+				case sem <- struct{}{}:
+				case <-cancel:
+					return
+				}
+				defer func() { <-sem }()
+				select {
+				case <-cancel:
+					return
+				default:
+				}
+				task()
+			})
+		}
+	}
+}
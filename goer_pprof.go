@@ -0,0 +1,22 @@
+//go:build !tinygo
+
+package deadline
+
+import (
+	"context"
+	"runtime/pprof"
+)
+
+// LabelsGoer returns middleware which runs tasks with given pprof labels set
+// on their goroutines. Labels are given as key-value pairs, as for
+// pprof.Labels().
+func LabelsGoer(labels ...string) GoerMiddleware {
+	set := pprof.Labels(labels...)
+	return wrapTask(func(task func()) func() {
+		return func() {
+			pprof.Do(context.Background(), set, func(context.Context) {
+				task()
+			})
+		}
+	})
+}
@@ -0,0 +1,73 @@
+package deadline
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestChainGoer(t *testing.T) {
+	var (
+		panics  = make(chan interface{}, 1)
+		started int32
+	)
+	g := ChainGoer(nil,
+		MetricsGoer(GoerMetrics{
+			Started: func(time.Duration) {
+				atomic.AddInt32(&started, 1)
+			},
+		}),
+		RecoverGoer(func(v interface{}) {
+			panics <- v
+		}),
+		LimitGoer(1),
+	)
+	d := Deadline{Goer: g}
+	d.Set(time.Now().Add(time.Second))
+	d.Do(func() {
+		panic("boom")
+	})
+	if v := <-panics; v != "boom" {
+		t.Errorf("unexpected recovered value: %v", v)
+	}
+	if atomic.LoadInt32(&started) != 1 {
+		t.Errorf("task start was not reported")
+	}
+}
+
+func TestLimitGoer(t *testing.T) {
+	g := ChainGoer(nil, LimitGoer(1))
+	release := make(chan struct{})
+	g(nil, func() { <-release })
+
+	var d Deadline
+	d.Set(time.Now().Add(10 * time.Millisecond))
+	d.Goer = g
+	err := d.Do(func() {
+		t.Errorf("task started over the limit")
+	})
+	if err != ErrDeadline {
+		t.Errorf("unexpected error: %v; want %v", err, ErrDeadline)
+	}
+	close(release)
+}
+
+func TestLimitGoerDropped(t *testing.T) {
+	var (
+		limit = LimitGoer(1)
+		drop  = func(<-chan struct{}, func()) {}
+		d     Deadline
+	)
+	d.Goer = ChainGoer(drop, limit)
+	for i := 0; i < 2; i++ {
+		d.SetTimeout(10 * time.Millisecond)
+		if err := d.Do(func() {}); err != ErrDeadline {
+			t.Fatalf("unexpected error: %v; want %v", err, ErrDeadline)
+		}
+	}
+	d.Goer = ChainGoer(nil, limit)
+	d.SetTimeout(time.Second)
+	if err := d.Do(func() {}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
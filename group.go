@@ -0,0 +1,83 @@
+package deadline
+
+import "sync"
+
+// Group is a collection of tasks running under the same deadline. It is much
+// like errgroup.Group: the first task error expires the group deadline for
+// the rest of tasks, which may observe it by Deadline().Done().
+//
+// Group deadline is a child of the given Deadline (see NewChild()), so
+// failure of the group does not expire the parent.
+type Group struct {
+	d  *Deadline
+	wg sync.WaitGroup
+
+	mu  sync.Mutex
+	err error
+}
+
+// NewGroup returns new Group bound to the deadline d.
+func NewGroup(d *Deadline) *Group {
+	return &Group{
+		d: NewChild(d),
+	}
+}
+
+// Deadline returns the group deadline. It expires when the parent deadline
+// expires, when some task fails, or when Wait() returns.
+func (g *Group) Deadline() *Deadline {
+	return g.d
+}
+
+// Go runs fn in a separate goroutine started by the Goer (or RejectGoer) of
+// the parent deadline. If fn returns an error, the group deadline is
+// canceled with that error. Task dropped by the RejectGoer fails with
+// ErrRejected.
+func (g *Group) Go(fn func() error) {
+	g.wg.Add(1)
+	// Task is started even after the group deadline expiration, since Wait()
+	// waits for all of the tasks.
+	g.d.start(nil, func() {
+		defer g.wg.Done()
+		if err := fn(); err != nil {
+			g.fail(err)
+		}
+	}, func() {
+		defer g.wg.Done()
+		g.fail(ErrRejected)
+	})
+}
+
+func (g *Group) fail(err error) {
+	g.mu.Lock()
+	first := g.err == nil
+	if first {
+		g.err = err
+	}
+	g.mu.Unlock()
+	if first {
+		g.d.Cancel(err)
+	}
+}
+
+// Wait waits for all tasks to finish, as errgroup.Group's Wait() does. It
+// returns the first task error, if any. If the group deadline expired before
+// all tasks were finished, it returns the expiration reason (ErrDeadline or
+// the like).
+func (g *Group) Wait() error {
+	g.wg.Wait()
+	// Expiration observed right after the tasks finished means that they
+	// were interrupted by it.
+	expired := isExpired(g.d)
+	g.mu.Lock()
+	err := g.err
+	g.mu.Unlock()
+	if err == nil && expired {
+		err = g.d.expired()
+	}
+	if g.d.Err() == nil {
+		// Release the link to the parent deadline.
+		g.d.Cancel(nil)
+	}
+	return err
+}
@@ -0,0 +1,78 @@
+package deadline
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestGroup(t *testing.T) {
+	var d Deadline
+	d.SetTimeout(time.Second)
+
+	g := NewGroup(&d)
+	for i := 0; i < 3; i++ {
+		g.Go(func() error {
+			time.Sleep(5 * time.Millisecond)
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	errTask := errors.New("task failed")
+	g = NewGroup(&d)
+	done := g.Deadline().Done()
+	g.Go(func() error {
+		return errTask
+	})
+	g.Go(func() error {
+		<-done
+		return nil
+	})
+	if err := g.Wait(); err != errTask {
+		t.Fatalf("unexpected error: %v; want %v", err, errTask)
+	}
+	if err := d.Err(); err != nil {
+		t.Fatalf("parent deadline expired by group failure: %v", err)
+	}
+
+	d.SetTimeout(10 * time.Millisecond)
+	g = NewGroup(&d)
+	done = g.Deadline().Done()
+	g.Go(func() error {
+		<-done
+		return nil
+	})
+	if err := g.Wait(); err != ErrDeadline {
+		t.Fatalf("unexpected error: %v; want %v", err, ErrDeadline)
+	}
+}
+
+func TestGroupWaitAll(t *testing.T) {
+	var d Deadline
+	d.SetTimeout(time.Second)
+
+	var (
+		g        = NewGroup(&d)
+		errTask  = errors.New("task failed")
+		finished = make(chan struct{})
+	)
+	g.Go(func() error {
+		return errTask
+	})
+	g.Go(func() error {
+		time.Sleep(20 * time.Millisecond)
+		close(finished)
+		return nil
+	})
+	if err := g.Wait(); err != errTask {
+		t.Fatalf("unexpected error: %v; want %v", err, errTask)
+	}
+	select {
+	case <-finished:
+	default:
+		t.Errorf("Wait() returned before all tasks finished")
+	}
+}
@@ -0,0 +1,52 @@
+package deadline
+
+import "time"
+
+// Handle tracks the callback run by DoHandle(). It allows to wait for the
+// callback return even if it was abandoned due to the deadline expiration.
+type Handle struct {
+	done chan struct{}
+	took time.Duration
+	err  error
+}
+
+// Completed returns a channel which is closed when the callback returns.
+// Note that it is never closed if the callback was not started at all, for
+// example, when Goer refused to start it.
+func (h *Handle) Completed() <-chan struct{} {
+	return h.done
+}
+
+// Err returns the error reported by DoHandle().
+func (h *Handle) Err() error {
+	return h.err
+}
+
+// Duration returns the callback duration. It returns zero if the callback is
+// not completed yet.
+func (h *Handle) Duration() time.Duration {
+	select {
+	case <-h.done:
+		return h.took
+	default:
+		return 0
+	}
+}
+
+// DoHandle is like Do() but additionally returns Handle of the callback. It
+// is useful when some resources used by the callback must be released only
+// after it returns, even if DoHandle() returned due to the expiration.
+func (d *Deadline) DoHandle(cb func(), opts ...Option) (*Handle, error) {
+	h := &Handle{
+		done: make(chan struct{}),
+	}
+	h.err = d.Do(func() {
+		start := time.Now()
+		defer func() {
+			h.took = time.Since(start)
+			close(h.done)
+		}()
+		cb()
+	}, opts...)
+	return h, h.err
+}
@@ -0,0 +1,28 @@
+package deadline
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDeadlineDoHandle(t *testing.T) {
+	var d Deadline
+	d.Set(time.Now().Add(10 * time.Millisecond))
+	h, err := d.DoHandle(func() {
+		time.Sleep(50 * time.Millisecond)
+	})
+	if err != ErrDeadline || h.Err() != err {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := h.Duration(); n != 0 {
+		t.Errorf("unexpected duration of running callback: %s", n)
+	}
+	select {
+	case <-h.Completed():
+	case <-time.After(time.Second):
+		t.Fatalf("callback did not complete")
+	}
+	if n := h.Duration(); n < 50*time.Millisecond {
+		t.Errorf("unexpected duration: %s", n)
+	}
+}
@@ -0,0 +1,33 @@
+package deadline
+
+import "time"
+
+// IdleDeadline is a Deadline which expires after the full Interval passed
+// without Touch() calls. It is the shape of keepalive and idle connection
+// timeouts.
+//
+// Touch() moves the deadline point forward without taking any locks in most
+// cases (see Set()), so it is cheap enough to be called on every read or
+// write.
+type IdleDeadline struct {
+	Deadline
+
+	// Interval is the idle interval after which deadline expires.
+	Interval time.Duration
+}
+
+// NewIdleDeadline returns new IdleDeadline armed to expire after interval.
+func NewIdleDeadline(interval time.Duration) *IdleDeadline {
+	d := &IdleDeadline{
+		Interval: interval,
+	}
+	d.Touch()
+	return d
+}
+
+// Touch reports activity: it re-arms the deadline to expire after the
+// Interval since now. Touch() of already expired deadline re-arms it as
+// well.
+func (d *IdleDeadline) Touch() {
+	d.Set(d.now().Add(d.Interval))
+}
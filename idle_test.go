@@ -0,0 +1,36 @@
+package deadline
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIdleDeadline(t *testing.T) {
+	d := NewIdleDeadline(20 * time.Millisecond)
+	for i := 0; i < 5; i++ {
+		time.Sleep(10 * time.Millisecond)
+		d.Touch()
+	}
+	select {
+	case <-d.Done():
+		t.Fatalf("deadline expired while being touched")
+	default:
+	}
+	start := time.Now()
+	select {
+	case <-d.Done():
+	case <-time.After(time.Second):
+		t.Fatalf("deadline was not expired after idle interval")
+	}
+	if idle := time.Since(start); idle < 10*time.Millisecond {
+		t.Errorf("deadline expired after %s of idle", idle)
+	}
+}
+
+func BenchmarkIdleDeadlineTouch(b *testing.B) {
+	d := NewIdleDeadline(time.Hour)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		d.Touch()
+	}
+}
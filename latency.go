@@ -0,0 +1,130 @@
+package deadline
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Outcome describes how Do() call finished.
+type Outcome int
+
+const (
+	// OutcomeOK means that callback returned before the deadline.
+	OutcomeOK Outcome = iota
+	// OutcomeExpired means that deadline expired (or was canceled) before
+	// callback returned.
+	OutcomeExpired
+	// OutcomePanicked means that callback panicked before the deadline.
+	OutcomePanicked
+)
+
+func (o Outcome) String() string {
+	switch o {
+	case OutcomeOK:
+		return "ok"
+	case OutcomeExpired:
+		return "expired"
+	case OutcomePanicked:
+		return "panicked"
+	default:
+		return "unknown"
+	}
+}
+
+// DefaultLatencyWindow is the default number of latest samples kept by
+// LatencyRecorder per operation.
+const DefaultLatencyWindow = 1024
+
+// LatencyRecorder keeps latest latency samples of operations and allows to
+// query them for percentiles. It is fed by Do() when Deadline.Latency points
+// to it. Operation is identified by the deadline's Name.
+//
+// Note that for expired calls the recorded latency is the time passed until
+// the expiration, that is, a lower bound of the real callback latency.
+type LatencyRecorder struct {
+	// Window is the number of latest samples kept per operation. If zero,
+	// DefaultLatencyWindow is used.
+	Window int
+
+	mu  sync.Mutex
+	ops map[string]*latencyWindow
+}
+
+type latencyWindow struct {
+	samples []time.Duration
+	pos     int
+	total   int
+	expired int
+}
+
+// Record records latency sample for the given operation.
+func (r *LatencyRecorder) Record(op string, dur time.Duration, outcome Outcome) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.ops == nil {
+		r.ops = make(map[string]*latencyWindow)
+	}
+	w := r.ops[op]
+	if w == nil {
+		n := r.Window
+		if n <= 0 {
+			n = DefaultLatencyWindow
+		}
+		w = &latencyWindow{
+			samples: make([]time.Duration, 0, n),
+		}
+		r.ops[op] = w
+	}
+	if len(w.samples) < cap(w.samples) {
+		w.samples = append(w.samples, dur)
+	} else {
+		w.samples[w.pos] = dur
+		w.pos = (w.pos + 1) % len(w.samples)
+	}
+	w.total++
+	if outcome == OutcomeExpired {
+		w.expired++
+	}
+}
+
+// Percentile returns p-th percentile (0 < p <= 1) of kept latency samples of
+// the given operation. It returns false if there are no samples yet.
+func (r *LatencyRecorder) Percentile(op string, p float64) (time.Duration, bool) {
+	r.mu.Lock()
+	w := r.ops[op]
+	if w == nil || len(w.samples) == 0 {
+		r.mu.Unlock()
+		return 0, false
+	}
+	samples := make([]time.Duration, len(w.samples))
+	copy(samples, w.samples)
+	r.mu.Unlock()
+
+	sort.Sort(durations(samples))
+	i := int(p*float64(len(samples))+0.5) - 1
+	switch {
+	case i < 0:
+		i = 0
+	case i >= len(samples):
+		i = len(samples) - 1
+	}
+	return samples[i], true
+}
+
+// Count returns total number of recorded samples of the given operation and
+// the number of expired ones among them.
+func (r *LatencyRecorder) Count(op string) (total, expired int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if w := r.ops[op]; w != nil {
+		return w.total, w.expired
+	}
+	return 0, 0
+}
+
+type durations []time.Duration
+
+func (d durations) Len() int           { return len(d) }
+func (d durations) Less(i, j int) bool { return d[i] < d[j] }
+func (d durations) Swap(i, j int)      { d[i], d[j] = d[j], d[i] }
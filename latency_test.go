@@ -0,0 +1,68 @@
+package deadline
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLatencyRecorderPercentile(t *testing.T) {
+	r := LatencyRecorder{
+		Window: 100,
+	}
+	if _, ok := r.Percentile("op", 0.5); ok {
+		t.Fatalf("unexpected percentile without samples")
+	}
+	// Samples in window are 101..200ms.
+	for i := 1; i <= 200; i++ {
+		r.Record("op", time.Duration(i)*time.Millisecond, OutcomeOK)
+	}
+	for _, test := range []struct {
+		p   float64
+		exp time.Duration
+	}{
+		{0.5, 150 * time.Millisecond},
+		{0.95, 195 * time.Millisecond},
+		{1, 200 * time.Millisecond},
+		{0, 101 * time.Millisecond},
+	} {
+		act, _ := r.Percentile("op", test.p)
+		if act != test.exp {
+			t.Errorf("p%v = %s; want %s", test.p*100, act, test.exp)
+		}
+	}
+}
+
+func TestLatencyRecorderDo(t *testing.T) {
+	var r LatencyRecorder
+	d := Deadline{
+		Name:    "op",
+		Latency: &r,
+	}
+	d.Set(time.Now().Add(10 * time.Millisecond))
+	d.Do(func() {})
+	release := make(chan struct{})
+	defer close(release)
+	d.Do(func() { <-release })
+
+	total, expired := r.Count("op")
+	if total != 2 || expired != 1 {
+		t.Errorf("unexpected counts: %d total, %d expired; want 2 and 1", total, expired)
+	}
+}
+
+func TestLatencyRecorderDoPanic(t *testing.T) {
+	var r LatencyRecorder
+	d := Deadline{
+		Name:    "op",
+		Latency: &r,
+		Panics:  PanicReturn,
+	}
+	d.Set(time.Now().Add(time.Hour))
+	if _, ok := d.Do(func() { panic("boom") }).(*PanicError); !ok {
+		t.Fatalf("panic was not returned")
+	}
+	total, expired := r.Count("op")
+	if total != 1 || expired != 0 {
+		t.Errorf("unexpected counts: %d total, %d expired; want 1 and 0", total, expired)
+	}
+}
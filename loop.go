@@ -0,0 +1,63 @@
+package deadline
+
+import "time"
+
+// Loop is a deadline intended to be driven from a single event loop
+// goroutine. Unlike Deadline, it has no timers, channels or mutexes: the
+// loop is responsible for calling Tick() with the current time to detect
+// expiration.
+//
+// Loop is not safe for concurrent use.
+type Loop struct {
+	at      time.Time
+	expired bool
+	hooks   []func(time.Time)
+}
+
+// Set sets up new deadline point. Zero t means no deadline.
+func (l *Loop) Set(t time.Time) {
+	l.at = t
+	l.expired = false
+}
+
+// Deadline returns currently set deadline point.
+func (l *Loop) Deadline() time.Time {
+	return l.at
+}
+
+// OnExpire registers fn to be called by Tick() every time deadline expires.
+// Hook receives the time passed to Tick().
+func (l *Loop) OnExpire(fn func(now time.Time)) {
+	l.hooks = append(l.hooks, fn)
+}
+
+// Expired reports whether the deadline was detected as expired by Tick().
+func (l *Loop) Expired() bool {
+	return l.expired
+}
+
+// Remaining returns duration left until the deadline at the given time. It
+// returns -1 if deadline is not set.
+func (l *Loop) Remaining(now time.Time) time.Duration {
+	if l.at.IsZero() {
+		return -1
+	}
+	if n := l.at.Sub(now); n > 0 {
+		return n
+	}
+	return 0
+}
+
+// Tick checks the deadline for expiration at given time. It returns true and
+// calls expiry hooks if deadline expires at this tick. Subsequent calls
+// return false until deadline is set again.
+func (l *Loop) Tick(now time.Time) bool {
+	if l.expired || l.at.IsZero() || now.Before(l.at) {
+		return false
+	}
+	l.expired = true
+	for _, fn := range l.hooks {
+		fn(now)
+	}
+	return true
+}
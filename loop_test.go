@@ -0,0 +1,40 @@
+package deadline
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLoop(t *testing.T) {
+	var (
+		l     Loop
+		calls int
+		now   = time.Now()
+	)
+	l.OnExpire(func(time.Time) { calls++ })
+	if l.Tick(now) {
+		t.Errorf("not set loop deadline expired")
+	}
+	l.Set(now.Add(time.Second))
+	if l.Tick(now) {
+		t.Errorf("loop deadline expired too early")
+	}
+	if rem := l.Remaining(now); rem != time.Second {
+		t.Errorf("unexpected remaining time: %s", rem)
+	}
+	if !l.Tick(now.Add(time.Second)) || !l.Expired() {
+		t.Errorf("loop deadline was not expired")
+	}
+	if l.Tick(now.Add(2 * time.Second)) {
+		t.Errorf("loop deadline expired twice")
+	}
+	if calls != 1 {
+		t.Errorf("hook called %d times; want 1", calls)
+	}
+	if allocs := testing.AllocsPerRun(100, func() {
+		l.Set(now.Add(time.Second))
+		l.Tick(now.Add(time.Second))
+	}); allocs != 0 {
+		t.Errorf("loop allocates %v times per cycle; want 0", allocs)
+	}
+}
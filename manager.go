@@ -0,0 +1,524 @@
+package deadline
+
+import (
+	"bufio"
+	"container/heap"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Manager keeps track of live Deadlines which have Manager field pointing to
+// it. Deadline is live when it is armed and not expired yet or when some of
+// its Do() callbacks are still running.
+//
+// Manager also schedules expiration of its Deadlines using a single timer.
+// Deadlines expiring at the same time are grouped into buckets, which are
+// expired at once: all Done() channels of the bucket are closed under a
+// single lock acquisition and hooks are called from a single goroutine.
+type Manager struct {
+	// Manual disables Manager's timer. When true, expiration of the managed
+	// deadlines happens only within Advance() calls. It is intended for
+	// integration with external event loops. It must not be changed after
+	// first use of the Manager.
+	Manual bool
+
+	// Tick enables hierarchical timing wheel with given tick duration as the
+	// scheduling backend. Wheel makes scheduling of a deadline O(1) at the
+	// cost of the expiration precision: deadlines expire at the first tick
+	// at or after their deadline point. It must not be changed after first
+	// use of the Manager.
+	Tick time.Duration
+
+	// WheelSlots is the number of slots per level of the timing wheel. If
+	// zero, DefaultWheelSlots is used.
+	WheelSlots int
+
+	// Granularity is the precision of expiration of managed deadlines. When
+	// not zero, deadline points are rounded up to the multiple of
+	// Granularity, so nearby deadlines share the same bucket and single
+	// timer wakeup. It must not be changed after first use of the Manager.
+	Granularity time.Duration
+
+	// Clock is an optional source of time used to schedule the managed
+	// deadlines. If nil, RealClock is used. Managed deadlines with nil Clock
+	// use Manager's Clock; Set() of managed deadline with other Clock panics.
+	// It must not be changed after first use of the Manager.
+	Clock Clock
+
+	mu      sync.Mutex
+	live    map[*Deadline]struct{}
+	timer   Timer
+	buckets bucketHeap
+	index   map[int64]*bucket // Buckets by expiration time.
+	wheel   *wheel            // Used instead of buckets when Tick is set.
+}
+
+type bucket struct {
+	at        time.Time
+	deadlines []*Deadline
+	index     int // Index in the heap.
+}
+
+// schedule schedules expiration of d at t. It must be called with d.mu held.
+func (m *Manager) schedule(d *Deadline, t time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.scheduleLocked(d, t)
+}
+
+// scheduleLocked schedules expiration of d at t. It must be called with both
+// d.mu and m.mu held.
+func (m *Manager) scheduleLocked(d *Deadline, t time.Time) {
+	if m.Tick > 0 {
+		m.scheduleWheel(d, t)
+		return
+	}
+	if g := m.Granularity; g > 0 {
+		if r := t.Sub(t.Truncate(g)); r > 0 {
+			t = t.Add(g - r)
+		}
+	}
+	key := t.UnixNano()
+	b := m.index[key]
+	if b == nil {
+		if m.index == nil {
+			m.index = make(map[int64]*bucket)
+		}
+		b = &bucket{at: t}
+		m.index[key] = b
+		heap.Push(&m.buckets, b)
+	}
+	b.push(d)
+	if b.index == 0 {
+		m.rearm()
+	}
+}
+
+// scheduleWheel schedules expiration of d at t using the timing wheel. It
+// must be called with m.mu held.
+func (m *Manager) scheduleWheel(d *Deadline, t time.Time) {
+	if m.wheel == nil {
+		m.wheel = newWheel(m.Tick, m.WheelSlots, m.now())
+	}
+	first := m.wheel.n == 0
+	m.wheel.add(d, t)
+	if first {
+		m.rearm()
+	}
+}
+
+// unschedule cancels scheduled expiration of d. It returns false if d is
+// not scheduled (and so it is expired or being expired right now). It must
+// be called with d.mu held.
+func (m *Manager) unschedule(d *Deadline) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.unscheduleLocked(d)
+}
+
+// unscheduleLocked cancels scheduled expiration of d. It must be called with
+// both d.mu and m.mu held.
+func (m *Manager) unscheduleLocked(d *Deadline) bool {
+	b := d.bucket
+	if b == nil {
+		return false
+	}
+	last := len(b.deadlines) - 1
+	b.deadlines[d.index] = b.deadlines[last]
+	b.deadlines[d.index].index = d.index
+	b.deadlines[last] = nil
+	b.deadlines = b.deadlines[:last]
+	d.bucket = nil
+
+	if m.wheel != nil {
+		m.wheel.remove()
+		if m.wheel.n == 0 {
+			m.rearm()
+		}
+		return true
+	}
+	if len(b.deadlines) == 0 {
+		delete(m.index, b.at.UnixNano())
+		first := b.index == 0
+		heap.Remove(&m.buckets, b.index)
+		if first {
+			m.rearm()
+		}
+	}
+	return true
+}
+
+// rearm arms the timer for the earliest bucket. It must be called with m.mu
+// held.
+func (m *Manager) rearm() {
+	if m.Manual {
+		return
+	}
+	at, ok := m.next()
+	if !ok {
+		if m.timer != nil {
+			m.timer.Stop()
+		}
+		return
+	}
+	n := at.Sub(m.now())
+	if m.timer == nil {
+		m.timer = m.clock().AfterFunc(n, m.onTimer)
+	} else {
+		m.timer.Reset(n)
+	}
+}
+
+// SetAll sets all given deadlines to expire at t, as Set() does for each of
+// them. Deadlines must have their Manager field pointing to m. Deadlines are
+// rescheduled under a single acquisition of the Manager's lock, which makes
+// it much cheaper than calling Set() for each of them when, for example,
+// pushing the same idle deadline to many connections.
+//
+// Given deadlines must be distinct and must not be passed to concurrent
+// SetAll() calls.
+func (m *Manager) SetAll(ds []*Deadline, t time.Time) {
+	ts := make([]time.Time, len(ds))
+	for i, d := range ds {
+		if d.Manager != m {
+			panic("deadline: SetAll() of Deadline not managed by the Manager")
+		}
+		d.checkClock()
+		ts[i] = t
+		if d.link != nil {
+			ts[i] = d.link.bind(d, t)
+		}
+	}
+	for _, d := range ds {
+		if d.Stopwatch != nil {
+			d.Stopwatch.startOnce()
+		}
+		d.mu.Lock()
+	}
+	unlock := func() {
+		for _, d := range ds {
+			d.mu.Unlock()
+		}
+	}
+	for _, d := range ds {
+		if d.pooled {
+			unlock()
+			panic("deadline: Set() of released Deadline")
+		}
+	}
+
+	var failed []*Deadline
+	m.mu.Lock()
+	for _, d := range ds {
+		d.sync()
+		if d.armed && !m.unscheduleLocked(d) {
+			failed = append(failed, d)
+		}
+	}
+	m.mu.Unlock()
+	for _, d := range failed {
+		d.disarmFailed()
+	}
+
+	var (
+		armed   = make([]*Deadline, 0, len(ds))
+		expired []*Deadline
+	)
+	for i, d := range ds {
+		n, exp := d.reset(ts[i])
+		switch {
+		case exp:
+			expired = append(expired, d)
+		case n > 0:
+			armed = append(armed, d)
+		}
+	}
+	if len(armed) > 0 {
+		m.mu.Lock()
+		for _, d := range armed {
+			m.scheduleLocked(d, d.at)
+		}
+		m.mu.Unlock()
+		for _, d := range armed {
+			d.armed = true
+			d.register()
+			d.updateManager()
+		}
+	}
+	for _, d := range ds {
+		d.publish()
+	}
+	unlock()
+
+	for _, d := range ds {
+		d.emit(Event{Type: EventSet, At: d.Expires()})
+	}
+	for _, d := range expired {
+		d.runHooks()
+	}
+}
+
+// ExpireTagged expires all live deadlines having the given tag in their Tags.
+// Do() calls interrupted by this expiration return given cause instead of
+// ErrDeadline, if cause is not nil. It returns the number of expired
+// deadlines.
+//
+// It is intended to cut off all in-flight work associated with, for
+// example, a misbehaving upstream.
+func (m *Manager) ExpireTagged(tag string, cause error) int {
+	var ds []*Deadline
+	for _, d := range m.deadlines() {
+		for _, t := range d.Tags {
+			if t == tag {
+				ds = append(ds, d)
+				break
+			}
+		}
+	}
+	for _, d := range ds {
+		d.expireWithCause(cause)
+	}
+	return len(ds)
+}
+
+// Expired describes deadline expired by Advance().
+type Expired struct {
+	Deadline *Deadline
+	At       time.Time // Time the deadline was set to.
+}
+
+// Advance expires all deadlines which are due at the given time, calls their
+// expiry hooks and returns them. It is intended to be called by the event
+// loop on each iteration when Manual is true, but may be used to expire
+// deadlines earlier than timer does in other cases.
+func (m *Manager) Advance(now time.Time) []Expired {
+	var ret []Expired
+	for _, d := range m.expire(now) {
+		ret = append(ret, Expired{
+			Deadline: d,
+			At:       d.Expires(),
+		})
+	}
+	return ret
+}
+
+// Next returns the time of the earliest scheduled expiration. When timing
+// wheel is used (see Tick), it returns the time of the next wheel tick. It returns
+// false if there are no scheduled deadlines. Event loops may use it to
+// compute wait timeout.
+func (m *Manager) Next() (time.Time, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.next()
+}
+
+// next implements Next(). It must be called with m.mu held.
+func (m *Manager) next() (time.Time, bool) {
+	if m.wheel != nil {
+		return m.wheel.next()
+	}
+	if len(m.buckets) == 0 {
+		return time.Time{}, false
+	}
+	return m.buckets[0].at, true
+}
+
+func (m *Manager) onTimer() {
+	m.expire(m.now())
+}
+
+// clock returns Clock used by m.
+func (m *Manager) clock() Clock {
+	if m.Clock != nil {
+		return m.Clock
+	}
+	return RealClock
+}
+
+// now returns current time of the m's Clock.
+func (m *Manager) now() time.Time {
+	if m.Clock != nil {
+		return m.Clock.Now()
+	}
+	return time.Now()
+}
+
+// expire expires all buckets which are due at the given time.
+func (m *Manager) expire(now time.Time) []*Deadline {
+	var expired []*Deadline
+
+	m.mu.Lock()
+	if m.wheel != nil {
+		m.wheel.advance(now, func(d *Deadline) {
+			atomic.AddInt32(&d.hooking, 1)
+			close(d.done)
+			expired = append(expired, d)
+		})
+	}
+	for len(m.buckets) > 0 && !m.buckets[0].at.After(now) {
+		b := heap.Pop(&m.buckets).(*bucket)
+		delete(m.index, b.at.UnixNano())
+		for _, d := range b.deadlines {
+			d.bucket = nil
+			if at := d.claim(); at.After(now) {
+				// Deadline was extended by the lock-free Set().
+				m.scheduleLocked(d, at)
+				continue
+			}
+			// It is safe to close d.done without holding d.mu because
+			// Deadline awaits for its closure if it failed to unschedule
+			// itself. Note that d.mu may be held while waiting, so we must
+			// not try to lock it here.
+			atomic.AddInt32(&d.hooking, 1)
+			close(d.done)
+			expired = append(expired, d)
+		}
+	}
+	m.rearm()
+	m.mu.Unlock()
+
+	for _, d := range expired {
+		d.runHooks()
+	}
+	return expired
+}
+
+type bucketHeap []*bucket
+
+func (h bucketHeap) Len() int           { return len(h) }
+func (h bucketHeap) Less(i, j int) bool { return h[i].at.Before(h[j].at) }
+func (h bucketHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *bucketHeap) Push(x interface{}) {
+	b := x.(*bucket)
+	b.index = len(*h)
+	*h = append(*h, b)
+}
+
+func (h *bucketHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	b := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return b
+}
+
+// Dump writes human-readable report about all live deadlines and their
+// running callbacks to w. It is intended to be used for debugging, for
+// example, on receiving SIGUSR1.
+func (m *Manager) Dump(w io.Writer) error {
+	var (
+		now    = m.now()
+		stacks = stack(true)
+		bw     = bufio.NewWriter(w)
+	)
+	ds := m.deadlines()
+	fmt.Fprintf(bw, "%d live deadline(s)\n", len(ds))
+	for _, d := range ds {
+		d.mu.Lock()
+		at := d.at
+		calls := make([]*call, 0, len(d.running))
+		for c := range d.running {
+			calls = append(calls, c)
+		}
+		d.mu.Unlock()
+		sort.Sort(callsByStart(calls))
+
+		name := d.Name
+		if name == "" {
+			name = fmt.Sprintf("%p", d)
+		}
+		fmt.Fprintf(bw, "\ndeadline %q: ", name)
+		switch {
+		case at.IsZero():
+			fmt.Fprintf(bw, "not set")
+		case at.Before(now):
+			fmt.Fprintf(bw, "expired %s ago", now.Sub(at))
+		default:
+			fmt.Fprintf(bw, "expires in %s", at.Sub(now))
+		}
+		fmt.Fprintf(bw, "; %d running callback(s)\n", len(calls))
+
+		for _, c := range calls {
+			id := atomic.LoadUint64(&c.id)
+			fmt.Fprintf(bw, "\tcallback %q goroutine %d running for %s\n",
+				c.name, id, now.Sub(c.start),
+			)
+			if s := findStack(stacks, id); s != nil {
+				for _, line := range strings.Split(string(s), "\n") {
+					fmt.Fprintf(bw, "\t\t%s\n", line)
+				}
+			}
+		}
+	}
+	return bw.Flush()
+}
+
+func (m *Manager) deadlines() []*Deadline {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ds := make([]*Deadline, 0, len(m.live))
+	for d := range m.live {
+		ds = append(ds, d)
+	}
+	return ds
+}
+
+func (m *Manager) add(d *Deadline) {
+	m.mu.Lock()
+	if m.live == nil {
+		m.live = make(map[*Deadline]struct{})
+	}
+	m.live[d] = struct{}{}
+	m.mu.Unlock()
+}
+
+func (m *Manager) remove(d *Deadline) {
+	m.mu.Lock()
+	delete(m.live, d)
+	m.mu.Unlock()
+}
+
+// updateManager registers or unregisters d at d.Manager depending on whether
+// d is live. It also removes d from the debug registry when d is not armed
+// anymore. It must be called with d.mu held.
+func (d *Deadline) updateManager() {
+	var armed bool
+	if d.armed {
+		select {
+		case <-d.done:
+		default:
+			armed = true
+		}
+	}
+	if !armed {
+		d.unregister()
+	}
+	if d.Manager == nil {
+		return
+	}
+	live := armed || len(d.running) > 0
+	switch {
+	case live && !d.managed:
+		d.Manager.add(d)
+	case !live && d.managed:
+		d.Manager.remove(d)
+	}
+	d.managed = live
+}
+
+type callsByStart []*call
+
+func (c callsByStart) Len() int           { return len(c) }
+func (c callsByStart) Less(i, j int) bool { return c[i].start.Before(c[j].start) }
+func (c callsByStart) Swap(i, j int)      { c[i], c[j] = c[j], c[i] }
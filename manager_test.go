@@ -0,0 +1,276 @@
+package deadline
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestManagerDump(t *testing.T) {
+	var m Manager
+	d := Deadline{
+		Name:    "conn-read",
+		Manager: &m,
+	}
+	d.Set(time.Now().Add(time.Hour))
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		d.Do(func() {
+			close(started)
+			<-release
+		})
+	}()
+	<-started
+
+	var buf bytes.Buffer
+	if err := m.Dump(&buf); err != nil {
+		t.Fatal(err)
+	}
+	dump := buf.String()
+	expect := []string{
+		"1 live deadline(s)",
+		`deadline "conn-read": expires in`,
+		"1 running callback(s)",
+	}
+	if stack(false) != nil {
+		expect = append(expect, "TestManagerDump")
+	}
+	for _, s := range expect {
+		if !strings.Contains(dump, s) {
+			t.Errorf("dump does not contain %q:\n%s", s, dump)
+		}
+	}
+
+	close(release)
+	<-finished
+	d.Set(time.Time{})
+
+	buf.Reset()
+	m.Dump(&buf)
+	if s := buf.String(); s != "0 live deadline(s)\n" {
+		t.Errorf("unexpected dump after deadline reset:\n%s", s)
+	}
+}
+
+func TestManagerExpire(t *testing.T) {
+	var (
+		m     Manager
+		at    = time.Now().Add(10 * time.Millisecond)
+		ds    = make([]*Deadline, 100)
+		hooks = make(chan int, len(ds))
+	)
+	for i := range ds {
+		i := i
+		ds[i] = &Deadline{Manager: &m}
+		ds[i].OnExpire(func() { hooks <- i })
+		ds[i].Set(at)
+	}
+	// Stop some of them and re-arm some to later time.
+	if !ds[0].Stop() {
+		t.Fatalf("Stop() = false for armed deadline")
+	}
+	ds[1].Set(time.Now().Add(time.Hour))
+
+	for _, d := range ds[2:] {
+		select {
+		case <-d.Done():
+		case <-time.After(time.Second):
+			t.Fatalf("deadline was not expired")
+		}
+	}
+	for i := 2; i < len(ds); i++ {
+		<-hooks
+	}
+	for _, d := range ds[:2] {
+		select {
+		case <-d.Done():
+			t.Fatalf("stopped deadline was expired")
+		default:
+		}
+	}
+	if ds[2].Stop() {
+		t.Errorf("Stop() = true for expired deadline")
+	}
+	ds[1].Set(time.Now().Add(time.Millisecond))
+	<-ds[1].Done()
+}
+
+func TestManagerAdvance(t *testing.T) {
+	m := Manager{
+		Manual: true,
+	}
+	var (
+		now = time.Now()
+		d1  = Deadline{Manager: &m}
+		d2  = Deadline{Manager: &m}
+	)
+	d1.Set(now.Add(time.Millisecond))
+	d2.Set(now.Add(time.Hour))
+	if next, ok := m.Next(); !ok || !next.Equal(now.Add(time.Millisecond)) {
+		t.Errorf("unexpected next expiration: %s", next)
+	}
+	time.Sleep(10 * time.Millisecond)
+	select {
+	case <-d1.Done():
+		t.Fatalf("deadline of manual manager expired without Advance()")
+	default:
+	}
+	exp := m.Advance(now.Add(time.Second))
+	if len(exp) != 1 || exp[0].Deadline != &d1 {
+		t.Fatalf("unexpected expired deadlines: %v", exp)
+	}
+	<-d1.Done()
+}
+
+func TestManagerWheel(t *testing.T) {
+	m := Manager{
+		Manual:     true,
+		Tick:       time.Millisecond,
+		WheelSlots: 4,
+	}
+	var (
+		now     = time.Now()
+		offsets = []time.Duration{
+			10 * time.Millisecond,
+			17 * time.Millisecond,
+			100 * time.Millisecond,
+			300 * time.Millisecond, // Beyond the wheel range.
+		}
+		ds      = make([]Deadline, len(offsets))
+		expired = make(map[*Deadline]time.Time)
+	)
+	for i, off := range offsets {
+		ds[i].Manager = &m
+		ds[i].Set(now.Add(off))
+	}
+	ds[1].Stop()
+	for at := now; at.Before(now.Add(400 * time.Millisecond)); at = at.Add(time.Millisecond) {
+		for _, e := range m.Advance(at) {
+			expired[e.Deadline] = at
+		}
+	}
+	if _, ok := expired[&ds[1]]; ok {
+		t.Errorf("stopped deadline expired")
+	}
+	for i, off := range offsets {
+		if i == 1 {
+			continue
+		}
+		at, ok := expired[&ds[i]]
+		if !ok {
+			t.Errorf("deadline #%d did not expire", i)
+			continue
+		}
+		if late := at.Sub(now.Add(off)); late < 0 || late > 2*time.Millisecond {
+			t.Errorf("deadline #%d expired with %s error", i, late)
+		}
+	}
+	if _, ok := m.Next(); ok {
+		t.Errorf("wheel is not empty")
+	}
+}
+
+func TestManagerWheelTimer(t *testing.T) {
+	m := Manager{Tick: 5 * time.Millisecond}
+	d := Deadline{Manager: &m}
+	d.Set(time.Now().Add(20 * time.Millisecond))
+	select {
+	case <-d.Done():
+	case <-time.After(time.Second):
+		t.Fatalf("deadline was not reached")
+	}
+}
+
+func TestManagerGranularity(t *testing.T) {
+	m := Manager{
+		Manual:      true,
+		Granularity: 10 * time.Millisecond,
+	}
+	var (
+		now = time.Now().Add(time.Hour).Truncate(10 * time.Millisecond)
+		d1  = Deadline{Manager: &m}
+		d2  = Deadline{Manager: &m}
+	)
+	d1.Set(now.Add(1 * time.Millisecond))
+	d2.Set(now.Add(7 * time.Millisecond))
+	if next, ok := m.Next(); !ok || !next.Equal(now.Add(10*time.Millisecond)) {
+		t.Errorf("unexpected next expiration: %s", next)
+	}
+	if exp := m.Advance(now.Add(9 * time.Millisecond)); len(exp) != 0 {
+		t.Fatalf("unexpected expired deadlines: %v", exp)
+	}
+	if exp := m.Advance(now.Add(10 * time.Millisecond)); len(exp) != 2 {
+		t.Fatalf("unexpected expired deadlines: %v; want both", exp)
+	}
+}
+
+func TestManagerSetAll(t *testing.T) {
+	var (
+		m  Manager
+		ds = make([]*Deadline, 10)
+	)
+	for i := range ds {
+		ds[i] = &Deadline{Manager: &m}
+	}
+	// Arm some of the deadlines to make SetAll() re-arm them.
+	ds[0].Set(time.Now().Add(time.Hour))
+	ds[1].Set(time.Now().Add(time.Hour))
+
+	m.SetAll(ds, time.Now().Add(10*time.Millisecond))
+	for i, d := range ds {
+		select {
+		case <-d.Done():
+		case <-time.After(time.Second):
+			t.Fatalf("deadline #%d was not expired", i)
+		}
+	}
+	if _, ok := m.Next(); ok {
+		t.Errorf("manager has scheduled deadlines after expiration")
+	}
+
+	m.SetAll(ds, time.Now().Add(-time.Second))
+	for i, d := range ds {
+		select {
+		case <-d.Done():
+		default:
+			t.Fatalf("deadline #%d was not expired immediately", i)
+		}
+	}
+}
+
+func TestManagerExpireTagged(t *testing.T) {
+	var (
+		m        Manager
+		errUp    = errors.New("upstream is misbehaving")
+		affected = Deadline{Manager: &m, Tags: []string{"user:1", "upstream:db"}}
+		other    = Deadline{Manager: &m, Tags: []string{"upstream:cache"}}
+	)
+	affected.Set(time.Now().Add(time.Hour))
+	other.Set(time.Now().Add(time.Hour))
+
+	result := make(chan error)
+	go func() {
+		result <- affected.Do(func() {
+			time.Sleep(time.Second)
+		})
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	if n := m.ExpireTagged("upstream:db", errUp); n != 1 {
+		t.Errorf("expired %d deadlines; want 1", n)
+	}
+	if err := <-result; err != errUp {
+		t.Errorf("unexpected Do() error: %v; want %v", err, errUp)
+	}
+	select {
+	case <-other.Done():
+		t.Errorf("deadline with other tag expired")
+	default:
+	}
+}
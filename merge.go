@@ -0,0 +1,117 @@
+package deadline
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// Earliest returns new Deadline which expires when the earliest of the given
+// deadlines expires. Returned Deadline keeps tracking the given ones: it is
+// re-armed to the earliest deadline point on every Set(), Stop(), Clear() or
+// Cancel() of any of them. When some of the given deadlines is canceled, the
+// returned one is canceled with the same reason.
+//
+// It is intended for connections juggling read, write and session deadlines
+// which need to react on whichever comes first. Returned Deadline uses the
+// Goer, Manager and Clock of the first given deadline and is referenced by
+// all of the given ones until it is released by Release(). It panics if
+// given deadlines use different Clocks.
+//
+// Note that changes made by Manager's SetAll() are not tracked.
+func Earliest(ds ...*Deadline) *Deadline {
+	if len(ds) == 0 {
+		panic("deadline: no deadlines given")
+	}
+	for _, d := range ds[1:] {
+		if d.clock() != ds[0].clock() {
+			panic("deadline: deadlines use different Clocks")
+		}
+	}
+	m := &merge{
+		ds: ds,
+		out: &Deadline{
+			Goer:       ds[0].Goer,
+			RejectGoer: ds[0].RejectGoer,
+			Manager:    ds[0].Manager,
+			Clock:      ds[0].Clock,
+		},
+	}
+	m.out.merge = m
+	for _, d := range ds {
+		d.watch(m)
+	}
+	m.update()
+	return m.out
+}
+
+// merge keeps the output deadline of Earliest() in sync with its inputs.
+type merge struct {
+	mu       sync.Mutex
+	ds       []*Deadline
+	out      *Deadline
+	released bool
+}
+
+func (m *merge) update() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.released {
+		return
+	}
+	var at time.Time
+	for _, d := range m.ds {
+		if err := d.Err(); err != nil && !errors.Is(err, ErrDeadline) {
+			if m.out.Err() != err {
+				m.out.Cancel(err)
+			}
+			return
+		}
+		t := d.Expires()
+		if t.IsZero() {
+			continue
+		}
+		if at.IsZero() || t.Before(at) {
+			at = t
+		}
+	}
+	m.out.Set(at)
+}
+
+// release stops tracking of the inputs.
+func (m *merge) release() {
+	for _, d := range m.ds {
+		d.unwatch(m)
+	}
+	m.mu.Lock()
+	m.released = true
+	m.mu.Unlock()
+}
+
+// watch makes m to be updated on every change of d.
+func (d *Deadline) watch(m *merge) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	// Merges need to observe every Set().
+	d.sync()
+	d.merges = append(d.merges, m)
+	d.publish()
+}
+
+func (d *Deadline) unwatch(m *merge) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for i, x := range d.merges {
+		if x == m {
+			d.merges = append(d.merges[:i], d.merges[i+1:]...)
+			return
+		}
+	}
+}
+
+// notify updates merges watching d. It must be called without d.mu held.
+func notify(ms []*merge) {
+	for _, m := range ms {
+		m.update()
+	}
+}
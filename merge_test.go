@@ -0,0 +1,49 @@
+package deadline
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestEarliest(t *testing.T) {
+	var (
+		read    Deadline
+		write   Deadline
+		session Deadline
+	)
+	session.Set(time.Now().Add(time.Hour))
+	e := Earliest(&read, &write, &session)
+	if act, exp := e.Expires(), session.Expires(); !act.Equal(exp) {
+		t.Fatalf("Expires() = %s; want %s", act, exp)
+	}
+
+	write.Set(time.Now().Add(time.Minute))
+	if act, exp := e.Expires(), write.Expires(); !act.Equal(exp) {
+		t.Fatalf("Expires() = %s; want %s", act, exp)
+	}
+	read.Set(time.Now().Add(10 * time.Millisecond))
+	select {
+	case <-e.Done():
+	case <-time.After(time.Second):
+		t.Fatalf("earliest deadline was not reached")
+	}
+
+	// Re-arm of the expired input re-arms the result.
+	read.Set(time.Now().Add(time.Hour))
+	select {
+	case <-e.Done():
+		t.Fatalf("result is expired after inputs re-armed")
+	default:
+	}
+	if act, exp := e.Expires(), write.Expires(); !act.Equal(exp) {
+		t.Fatalf("Expires() = %s; want %s", act, exp)
+	}
+
+	errClosed := errors.New("closed")
+	session.Cancel(errClosed)
+	<-e.Done()
+	if err := e.Err(); err != errClosed {
+		t.Errorf("unexpected error: %v; want %v", err, errClosed)
+	}
+}
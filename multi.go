@@ -0,0 +1,43 @@
+package deadline
+
+// DoEarliest runs callback in a separate goroutine under the all given
+// deadlines. It returns when callback returns or when the earliest of the
+// deadlines expires. In case of expiration, it returns *ExhaustedError which
+// identifies the expired deadline. Goroutine is started with the Goer of the
+// first deadline.
+//
+// It is useful when operation is bounded by several budgets at once, for
+// example, by per-read and total request deadlines.
+func DoEarliest(cb func(), ds ...*Deadline) error {
+	if len(ds) == 0 {
+		panic("deadline: no deadlines given")
+	}
+	var (
+		ok      = make(chan struct{})
+		stop    = make(chan struct{})
+		expired = make(chan *Deadline, len(ds))
+	)
+	defer close(stop)
+	for _, d := range ds {
+		go func(d *Deadline, done <-chan struct{}) {
+			select {
+			case <-done:
+				expired <- d
+			case <-ok:
+			case <-stop:
+			}
+		}(d, d.Done())
+	}
+	goer(ds[0].Goer, ds[0].Done(), func() {
+		defer close(ok)
+		cb()
+	})
+	select {
+	case <-ok:
+		return nil
+	case d := <-expired:
+		return &ExhaustedError{
+			Deadline: d,
+		}
+	}
+}
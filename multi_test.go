@@ -0,0 +1,35 @@
+package deadline
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDoEarliest(t *testing.T) {
+	var (
+		read  = Deadline{Name: "read"}
+		total = Deadline{Name: "total"}
+	)
+	read.Set(time.Now().Add(10 * time.Millisecond))
+	total.Set(time.Now().Add(time.Hour))
+
+	release := make(chan struct{})
+	defer close(release)
+	err := DoEarliest(func() {
+		<-release
+	}, &read, &total)
+	e, ok := err.(*ExhaustedError)
+	if !ok {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if e.Deadline != &read {
+		t.Errorf("unexpected exhausted deadline: %q", e.Deadline.Name)
+	}
+	if act, exp := e.Error(), "read: deadline exceeded"; act != exp {
+		t.Errorf("unexpected error text: %q; want %q", act, exp)
+	}
+
+	if err := DoEarliest(func() {}, &total); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
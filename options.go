@@ -0,0 +1,174 @@
+package deadline
+
+import (
+	"sync"
+	"time"
+)
+
+// Option configures single Do() call.
+type Option func(*callOptions)
+
+type callOptions struct {
+	name     string
+	deadline time.Time
+	goer     GoFunc
+	rgoer    RejectGoFunc
+	trace    *Trace
+	panics   PanicMode
+	err      error
+	grace    time.Duration
+	abandon  func()
+}
+
+func (o *callOptions) apply(opts []Option) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+// WithName sets the operation name of the call. It overrides Deadline's Name
+// for the latency recording and diagnostics.
+func WithName(name string) Option {
+	return func(o *callOptions) {
+		o.name = name
+	}
+}
+
+// WithDeadline sets additional deadline for the call. Do() returns when the
+// earliest of Deadline's and given deadline is reached. Note that Goer is
+// notified only by the Deadline's expiration.
+func WithDeadline(t time.Time) Option {
+	return func(o *callOptions) {
+		o.deadline = t
+	}
+}
+
+// WithGoer sets goroutine starter for the call overriding Deadline's Goer
+// and RejectGoer. It can be used to prioritize calls, for example, by running
+// them on different goroutine pools.
+func WithGoer(g GoFunc) Option {
+	return func(o *callOptions) {
+		o.goer = g
+		o.rgoer = nil
+	}
+}
+
+// WithRejectGoer sets goroutine starter for the call overriding Deadline's
+// Goer and RejectGoer. Unlike WithGoer(), given starter is able to report that it dropped
+// the callback; in that case Do() returns ErrRejected without waiting for the
+// deadline.
+func WithRejectGoer(g RejectGoFunc) Option {
+	return func(o *callOptions) {
+		o.goer = nil
+		o.rgoer = g
+	}
+}
+
+// WithTrace sets hooks called during the call.
+func WithTrace(t *Trace) Option {
+	return func(o *callOptions) {
+		o.trace = t
+	}
+}
+
+// WithRecover makes Do() to recover panic of the callback and return it as
+// *PanicError. Note that panic which happens after Do() returned due to the
+// deadline expiration is recovered and dropped.
+//
+// It is the same as WithPanicMode(PanicReturn).
+func WithRecover() Option {
+	return WithPanicMode(PanicReturn)
+}
+
+// WithPanicMode sets the way callback panics are handled by the call,
+// overriding Deadline's Panics.
+func WithPanicMode(m PanicMode) Option {
+	return func(o *callOptions) {
+		o.panics = m
+	}
+}
+
+// WithError sets an error returned by Do() instead of ErrDeadline when the
+// deadline expires.
+func WithError(err error) Option {
+	return func(o *callOptions) {
+		o.err = err
+	}
+}
+
+// WithGrace sets the grace period given to the callback after the deadline
+// expiration. Deadline waiters are notified as usual, but Do() waits up to
+// grace for the callback to return before considering it abandoned. If
+// callback does not return within grace, abandon is called (if not nil)
+// right before Do() returns. In both cases Do() returns the expiration
+// error.
+//
+// It is intended for cooperative callbacks which observe the deadline (see
+// DoContext()) and need a moment to clean up.
+func WithGrace(grace time.Duration, abandon func()) Option {
+	return func(o *callOptions) {
+		o.grace = grace
+		o.abandon = abandon
+	}
+}
+
+// Trace contains hooks called during single Do() call. Any of them can be nil.
+//
+// Hooks are intended to be bridged into tracing systems: for example, Start
+// and Return may open and end a span of the callback, while Expire and Late
+// record span events about the timeout and the late completion.
+type Trace struct {
+	// Start is called from the callback goroutine right before the callback.
+	Start func()
+
+	// Return is called from the callback goroutine when callback returns, even
+	// if Do() already returned due to the deadline expiration.
+	Return func(took time.Duration)
+
+	// Done is called with Do() result right before Do() returns.
+	Done func(err error)
+
+	// Expire is called when Do() returns due to the expiration, with the
+	// deadline point which was exceeded. It is called before Done.
+	Expire func(at time.Time)
+
+	// Late is called from the callback goroutine when callback returns after
+	// Do() returned due to the expiration. It receives the duration between
+	// the exceeded deadline point and the callback return.
+	Late func(late time.Duration)
+}
+
+// lateTracker detects callbacks returned after Do() expiration.
+type lateTracker struct {
+	mu       sync.Mutex
+	returned bool
+	at       time.Time
+}
+
+func (l *lateTracker) wrap(late func(time.Duration), cb func()) func() {
+	return func() {
+		cb()
+		l.mu.Lock()
+		l.returned = true
+		at := l.at
+		l.mu.Unlock()
+		if !at.IsZero() {
+			late(time.Since(at))
+		}
+	}
+}
+
+// expire marks Do() as returned due to the expiration of the deadline at. If
+// callback is still running, abandon is called (if not nil) before the late
+// hook could be called.
+func (l *lateTracker) expire(at time.Time, abandon func()) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.returned {
+		return
+	}
+	l.at = at
+	if abandon != nil {
+		abandon()
+	}
+}
@@ -0,0 +1,189 @@
+package deadline
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDoWithDeadline(t *testing.T) {
+	var d Deadline
+	d.Set(time.Now().Add(time.Hour))
+	release := make(chan struct{})
+	defer close(release)
+	err := d.Do(func() {
+		<-release
+	}, WithDeadline(time.Now().Add(10*time.Millisecond)))
+	if err != ErrDeadline {
+		t.Fatalf("unexpected error: %v; want %v", err, ErrDeadline)
+	}
+}
+
+func TestDoWithNameAndTrace(t *testing.T) {
+	var (
+		r      LatencyRecorder
+		events []string
+	)
+	d := Deadline{
+		Name:    "conn",
+		Latency: &r,
+	}
+	err := d.Do(func() {
+		events = append(events, "callback")
+	}, WithName("handshake"), WithTrace(&Trace{
+		Start: func() {
+			events = append(events, "start")
+		},
+		Return: func(time.Duration) {
+			events = append(events, "return")
+		},
+		Done: func(err error) {
+			events = append(events, "done")
+		},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := r.Count("handshake"); n != 1 {
+		t.Errorf("latency of the call was not recorded under its name")
+	}
+	exp := []string{"start", "callback", "return", "done"}
+	if len(events) != len(exp) {
+		t.Fatalf("unexpected events: %v; want %v", events, exp)
+	}
+	for i := range exp {
+		if events[i] != exp[i] {
+			t.Fatalf("unexpected events: %v; want %v", events, exp)
+		}
+	}
+}
+
+func TestDoOptions(t *testing.T) {
+	errTimeout := errors.New("timed out")
+
+	err := Do(time.Now().Add(time.Millisecond), func() {
+		time.Sleep(10 * time.Millisecond)
+	}, WithError(errTimeout))
+	if err != errTimeout {
+		t.Errorf("unexpected error: %v; want %v", err, errTimeout)
+	}
+
+	var started bool
+	err = Do(time.Now().Add(time.Second), func() {
+		panic("boom")
+	}, WithRecover(), WithGoer(func(_ <-chan struct{}, task func()) {
+		started = true
+		go task()
+	}))
+	if !started {
+		t.Errorf("goer was not used")
+	}
+	if p, ok := err.(*PanicError); !ok || p.Value != "boom" {
+		t.Errorf("unexpected error: %v; want panic error", err)
+	}
+}
+
+func TestDeadlinePanicMode(t *testing.T) {
+	d := Deadline{
+		Panics: PanicReturn,
+	}
+	d.Set(time.Now().Add(time.Second))
+	err := d.Do(func() {
+		panic("boom")
+	})
+	if p, ok := err.(*PanicError); !ok || p.Value != "boom" || len(p.Stack) == 0 {
+		t.Errorf("unexpected error: %v; want panic error", err)
+	}
+
+	defer func() {
+		v := recover()
+		if p, ok := v.(*PanicError); !ok || p.Value != "boom" {
+			t.Errorf("unexpected panic: %v; want panic error", v)
+		}
+	}()
+	d.Do(func() {
+		panic("boom")
+	}, WithPanicMode(PanicRepanic))
+	t.Errorf("no panic")
+}
+
+func TestDoTraceExpire(t *testing.T) {
+	var (
+		expired time.Time
+		late    = make(chan time.Duration, 1)
+	)
+	at := time.Now().Add(10 * time.Millisecond)
+	err := Do(at, func() {
+		time.Sleep(50 * time.Millisecond)
+	}, WithTrace(&Trace{
+		Expire: func(at time.Time) {
+			expired = at
+		},
+		Late: func(d time.Duration) {
+			late <- d
+		},
+	}))
+	if err != ErrDeadline {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !expired.Equal(at) {
+		t.Errorf("unexpected expired deadline: %s; want %s", expired, at)
+	}
+	select {
+	case d := <-late:
+		if d < 30*time.Millisecond {
+			t.Errorf("unexpected late duration: %s", d)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("late completion was not reported")
+	}
+}
+
+func TestDoWithGrace(t *testing.T) {
+	var (
+		abandoned bool
+		returned  bool
+	)
+	err := Do(time.Now().Add(10*time.Millisecond), func() {
+		time.Sleep(20 * time.Millisecond)
+		returned = true
+	}, WithGrace(time.Second, func() {
+		abandoned = true
+	}))
+	if err != ErrDeadline {
+		t.Fatalf("unexpected error: %v; want %v", err, ErrDeadline)
+	}
+	if !returned || abandoned {
+		t.Fatalf("callback was not given a grace period")
+	}
+
+	release := make(chan struct{})
+	defer close(release)
+	err = Do(time.Now().Add(10*time.Millisecond), func() {
+		<-release
+	}, WithGrace(10*time.Millisecond, func() {
+		abandoned = true
+	}))
+	if err != ErrDeadline {
+		t.Fatalf("unexpected error: %v; want %v", err, ErrDeadline)
+	}
+	if !abandoned {
+		t.Fatalf("abandon hook was not called")
+	}
+}
+
+func TestDoLateGoerNotRejected(t *testing.T) {
+	d := Deadline{
+		Goer: func(_ <-chan struct{}, task func()) {
+			go func() {
+				time.Sleep(20 * time.Millisecond)
+				task()
+			}()
+		},
+	}
+	d.Set(time.Now().Add(10 * time.Millisecond))
+	err := d.Do(func() {})
+	if err != ErrDeadline {
+		t.Errorf("unexpected error: %v; want %v", err, ErrDeadline)
+	}
+}
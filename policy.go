@@ -0,0 +1,119 @@
+package deadline
+
+import "time"
+
+// Policy suggests timeouts for the operations.
+type Policy interface {
+	// Timeout returns timeout for the operation named by op.
+	Timeout(op string) time.Duration
+}
+
+// PolicyFunc is an adapter to allow the use of ordinary functions as Policy.
+type PolicyFunc func(op string) time.Duration
+
+// Timeout implements Policy.
+func (f PolicyFunc) Timeout(op string) time.Duration { return f(op) }
+
+// DefaultAdaptivePercentile is a percentile of observed latencies used by
+// AdaptiveTarget when its Percentile field is zero.
+const DefaultAdaptivePercentile = 0.99
+
+// AdaptiveTarget describes how the target timeout is computed from observed
+// latencies of the operation:
+//
+//	target = percentile(Percentile) * Multiplier + Headroom
+type AdaptiveTarget struct {
+	// Percentile is a percentile of observed latencies, such as 0.5, 0.95 or
+	// 0.999. If zero, DefaultAdaptivePercentile is used.
+	Percentile float64
+
+	// Multiplier is a multiplicative headroom. If zero, 1 is used.
+	Multiplier float64
+
+	// Headroom is an additive headroom.
+	Headroom time.Duration
+}
+
+func (t AdaptiveTarget) timeout(r *LatencyRecorder, op string) time.Duration {
+	p := t.Percentile
+	if p == 0 {
+		p = DefaultAdaptivePercentile
+	}
+	m := t.Multiplier
+	if m == 0 {
+		m = 1
+	}
+	v, _ := r.Percentile(op, p)
+	return time.Duration(float64(v)*m) + t.Headroom
+}
+
+// AdaptivePolicy is a Policy suggesting timeouts based on the latencies
+// observed by LatencyRecorder.
+//
+// Until WarmUp samples are recorded for the operation, Default timeout is
+// suggested. After that suggestion moves linearly from Default towards the
+// percentile-based target during next Blend samples. Target is computed as
+// described by Targets entry for the operation or by Target if there is no
+// such entry.
+type AdaptivePolicy struct {
+	// Recorder is a source of latency samples.
+	Recorder *LatencyRecorder
+
+	// Target describes target timeout computation for the operations which
+	// are not present in Targets.
+	Target AdaptiveTarget
+
+	// Targets contains per-operation target timeout computation settings.
+	Targets map[string]AdaptiveTarget
+
+	// Default is a static timeout used while operation is warming up.
+	Default time.Duration
+
+	// WarmUp is a number of samples which must be recorded for the operation
+	// before observed latencies are taken into account.
+	WarmUp int
+
+	// Blend is a number of samples after warm-up during which suggested
+	// timeout is a mix of Default and the target. If zero, target is used
+	// right after warm-up.
+	Blend int
+}
+
+// Timeout implements Policy.
+func (p *AdaptivePolicy) Timeout(op string) time.Duration {
+	n, _ := p.Recorder.Count(op)
+	if n == 0 || n < p.WarmUp {
+		return p.Default
+	}
+	t, ok := p.Targets[op]
+	if !ok {
+		t = p.Target
+	}
+	target := t.timeout(p.Recorder, op)
+	if k := n - p.WarmUp; k < p.Blend {
+		w := float64(k) / float64(p.Blend)
+		return p.Default + time.Duration(w*float64(target-p.Default))
+	}
+	return target
+}
+
+// Static returns Policy suggesting the same timeout for every operation.
+func Static(timeout time.Duration) Policy {
+	return PolicyFunc(func(string) time.Duration {
+		return timeout
+	})
+}
+
+// Cap returns Policy suggesting timeouts of p but never greater than
+// suggested by max. That is, it guarantees that no operation gets more time
+// than its contractual limit even if p is adaptive and observed latencies
+// drift upward.
+func Cap(p, max Policy) Policy {
+	return PolicyFunc(func(op string) time.Duration {
+		t := p.Timeout(op)
+		if m := max.Timeout(op); m < t {
+			return m
+		}
+		return t
+	})
+}
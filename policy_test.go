@@ -0,0 +1,89 @@
+package deadline
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAdaptivePolicyWarmUp(t *testing.T) {
+	var r LatencyRecorder
+	p := AdaptivePolicy{
+		Recorder: &r,
+		Target: AdaptiveTarget{
+			Percentile: 1,
+		},
+		Default: time.Second,
+		WarmUp:  10,
+		Blend:   10,
+	}
+	for _, test := range []struct {
+		samples int
+		exp     time.Duration
+	}{
+		{0, time.Second},
+		{10, time.Second},
+		{15, 550 * time.Millisecond},
+		{20, 100 * time.Millisecond},
+		{30, 100 * time.Millisecond},
+	} {
+		for n, _ := r.Count("op"); n < test.samples; n++ {
+			r.Record("op", 100*time.Millisecond, OutcomeOK)
+		}
+		if act := p.Timeout("op"); act != test.exp {
+			t.Errorf(
+				"timeout after %d samples is %s; want %s",
+				test.samples, act, test.exp,
+			)
+		}
+	}
+}
+
+func TestAdaptivePolicyTargets(t *testing.T) {
+	var r LatencyRecorder
+	for i := 1; i <= 100; i++ {
+		r.Record("cheap", time.Duration(i)*time.Millisecond, OutcomeOK)
+		r.Record("report", time.Duration(i)*time.Second, OutcomeOK)
+	}
+	p := AdaptivePolicy{
+		Recorder: &r,
+		Target: AdaptiveTarget{
+			Percentile: 0.5,
+		},
+		Targets: map[string]AdaptiveTarget{
+			"report": {
+				Percentile: 0.95,
+				Multiplier: 2,
+				Headroom:   time.Second,
+			},
+		},
+	}
+	for _, test := range []struct {
+		op  string
+		exp time.Duration
+	}{
+		{"cheap", 50 * time.Millisecond},
+		{"report", 191 * time.Second},
+	} {
+		if act := p.Timeout(test.op); act != test.exp {
+			t.Errorf("timeout for %q is %s; want %s", test.op, act, test.exp)
+		}
+	}
+}
+
+func TestCap(t *testing.T) {
+	p := Cap(
+		PolicyFunc(func(op string) time.Duration {
+			if op == "slow" {
+				return time.Minute
+			}
+			return time.Millisecond
+		}),
+		Static(time.Second),
+	)
+	if act, exp := p.Timeout("slow"), time.Second; act != exp {
+		t.Errorf("capped timeout is %s; want %s", act, exp)
+	}
+	if act, exp := p.Timeout("fast"), time.Millisecond; act != exp {
+		t.Errorf("capped timeout is %s; want %s", act, exp)
+	}
+}
@@ -0,0 +1,24 @@
+package deadline
+
+import "time"
+
+// WaitFor polls cond every interval until it returns true or error, or until
+// the deadline d expires. Condition is checked immediately first. It returns
+// nil if condition is met, the error returned by cond, or the expiration
+// reason as returned by Sleep().
+//
+// Note that cond calls are not interrupted by the deadline.
+func WaitFor(d *Deadline, interval time.Duration, cond func() (bool, error)) error {
+	for {
+		ok, err := cond()
+		if err != nil {
+			return err
+		}
+		if ok {
+			return nil
+		}
+		if err := Sleep(d, interval); err != nil {
+			return err
+		}
+	}
+}
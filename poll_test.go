@@ -0,0 +1,36 @@
+package deadline
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWaitFor(t *testing.T) {
+	var d Deadline
+	d.SetTimeout(time.Second)
+	n := 0
+	err := WaitFor(&d, time.Millisecond, func() (bool, error) {
+		n++
+		return n == 3, nil
+	})
+	if err != nil || n != 3 {
+		t.Fatalf("WaitFor() = %v after %d calls; want nil after 3", err, n)
+	}
+
+	errCond := errors.New("condition failed")
+	err = WaitFor(&d, time.Millisecond, func() (bool, error) {
+		return false, errCond
+	})
+	if err != errCond {
+		t.Fatalf("unexpected error: %v; want %v", err, errCond)
+	}
+
+	d.SetTimeout(10 * time.Millisecond)
+	err = WaitFor(&d, time.Millisecond, func() (bool, error) {
+		return false, nil
+	})
+	if err != ErrDeadline {
+		t.Fatalf("unexpected error: %v; want %v", err, ErrDeadline)
+	}
+}
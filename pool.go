@@ -0,0 +1,193 @@
+package deadline
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// noPooling is non-zero when pooling of Deadlines is disabled.
+var noPooling int32
+
+// EnablePooling enables or disables the internal pool used by Acquire() and
+// Release(). Pooling is enabled by default. When disabled, Acquire()
+// allocates new Deadline every time and Release() just resets the Deadline
+// state, which makes memory usage more predictable, e.g. for debugging.
+func EnablePooling(enable bool) {
+	var v int32
+	if !enable {
+		v = 1
+	}
+	atomic.StoreInt32(&noPooling, v)
+}
+
+func pooling() bool {
+	return atomic.LoadInt32(&noPooling) == 0
+}
+
+// Allocator allocates Done() channels of the Deadline. Returned channel must
+// be open and must not be referenced by anything else; it is never given
+// back to the Allocator, since Done() channels are not recycled.
+type Allocator func() chan struct{}
+
+// Preallocate returns Allocator which allocates channels in batches of n
+// and gives them out one by one. First batch is allocated immediately. It
+// lets to move allocations out of the hot path, e.g. when deadline is
+// re-armed after each expiration. Returned Allocator is safe for concurrent
+// use by multiple Deadlines.
+func Preallocate(n int) Allocator {
+	if n <= 0 {
+		n = 1
+	}
+	var (
+		mu   sync.Mutex
+		free = make([]chan struct{}, 0, n)
+	)
+	fill := func() {
+		for i := 0; i < n; i++ {
+			free = append(free, makeChan())
+		}
+	}
+	fill()
+	return func() chan struct{} {
+		mu.Lock()
+		defer mu.Unlock()
+		if len(free) == 0 {
+			fill()
+		}
+		last := len(free) - 1
+		ch := free[last]
+		free[last] = nil
+		free = free[:last]
+		return ch
+	}
+}
+
+// newDone returns new done channel for d.
+func (d *Deadline) newDone() chan struct{} {
+	if d.Alloc != nil {
+		return d.Alloc()
+	}
+	return acquireDone()
+}
+
+// Acquire returns Deadline from the internal pool. Returned Deadline is not
+// set and has zero configuration. It should be returned to the pool by
+// Release() when it is no longer needed.
+func Acquire() *Deadline {
+	if !pooling() {
+		count(&poolStats.DeadlineMisses)
+		return new(Deadline)
+	}
+	if d := getDeadline(); d != nil {
+		count(&poolStats.DeadlineHits)
+		d.mu.Lock()
+		d.pooled = false
+		d.mu.Unlock()
+		return d
+	}
+	count(&poolStats.DeadlineMisses)
+	return new(Deadline)
+}
+
+// Release resets the Deadline state and puts it back to the internal pool.
+// It disarms the deadline and waits for running expiry hooks to finish. It
+// panics if d is already released or if some of d's Do() callbacks tracked
+// by d.Manager are still running.
+//
+// Note that d must not be used after Release(). Channels returned by Done()
+// before Release() will not be closed by the further use of d.
+func Release(d *Deadline) {
+	if d.merge != nil {
+		d.merge.release()
+	}
+	d.mu.Lock()
+	if d.pooled {
+		d.mu.Unlock()
+		panic("deadline: Release() of already released Deadline")
+	}
+	if len(d.running) > 0 {
+		d.mu.Unlock()
+		panic("deadline: Release() of Deadline with running callbacks")
+	}
+	d.set(time.Time{})
+	d.waitHooks()
+	if d.link != nil {
+		d.link.unlink()
+	}
+
+	var (
+		observer = d.Observer
+		now      = d.now()
+	)
+	d.zero()
+	d.pooled = true
+	d.mu.Unlock()
+
+	if observer != nil {
+		observer(Event{
+			Type:     EventSet,
+			Deadline: d,
+			Time:     now,
+		})
+	}
+
+	if pooling() {
+		putDeadline(d)
+	}
+}
+
+// zero resets d to the zero state. It must be called with d.mu held, which
+// is why d is not overwritten as a whole: stale expiration may wait for d.mu
+// right now. Generation of done channel is kept, so waiters of the released
+// deadline do not see their generation as current.
+func (d *Deadline) zero() {
+	// Make stale timer calls no-op. Timer must be dropped then, since it
+	// would call expire() with the stale epoch.
+	d.epoch++
+	if d.timer != nil {
+		d.timer.Stop()
+		d.timer = nil
+	}
+
+	d.Goer = nil
+	d.RejectGoer = nil
+	d.Alloc = nil
+	d.Clock = nil
+	d.Monotonic = false
+	d.Soft = 0
+	d.Name = ""
+	d.Tags = nil
+	d.Manager = nil
+	d.Latency = nil
+	d.Stopwatch = nil
+	d.PanicHandler = nil
+	d.Panics = PanicDefault
+	d.Observer = nil
+	d.OnLate = nil
+	d.CollectStats = false
+	d.Debug = nil
+	d.CaptureStack = false
+	d.CaptureStackRate = 0
+	d.RichErrors = false
+
+	d.done = nil
+	d.armed = false
+	d.cause = nil
+	d.at = time.Time{}
+	d.hooks = nil
+	d.once = nil
+	d.calls = nil
+	d.running = nil
+	d.managed = false
+	d.link = nil
+	d.merge = nil
+	d.merges = nil
+	d.warnings = nil
+	d.expireErr = nil
+	d.stats = stats{}
+	d.bucket = nil
+	d.index = 0
+	d.tick = 0
+	d.publish()
+}
@@ -0,0 +1,30 @@
+//go:build !js && !tinygo
+
+package deadline
+
+import "sync"
+
+// defaultScheduler is a Manager which schedules expiration of the Deadlines
+// which have no Manager. If nil, Deadlines use their own timers.
+var defaultScheduler *Manager
+
+var deadlinePool sync.Pool
+
+// Done channels are never recycled: channel may be referenced by the waiters
+// long after the Deadline replaced it, and reusing it would let such waiter
+// to observe closure related to some other deadline.
+func acquireDone() chan struct{} {
+	count(&poolStats.DoneMisses)
+	return makeChan()
+}
+
+func getDeadline() *Deadline {
+	if v := deadlinePool.Get(); v != nil {
+		return v.(*Deadline)
+	}
+	return nil
+}
+
+func putDeadline(d *Deadline) {
+	deadlinePool.Put(d)
+}
@@ -0,0 +1,23 @@
+//go:build js && !tinygo
+
+package deadline
+
+// defaultScheduler is a Manager which schedules expiration of the Deadlines
+// which have no Manager.
+//
+// Under js/wasm every Deadline's expiration is scheduled by the shared
+// Manager, so nearby expirations are coalesced into a single setTimeout-like
+// wakeup instead of keeping a runtime timer per Deadline.
+var defaultScheduler = new(Manager)
+
+// Pooling is disabled under js/wasm since sync.Pool gives no benefit in a
+// single-threaded runtime and makes memory usage less predictable.
+
+func acquireDone() chan struct{} {
+	count(&poolStats.DoneMisses)
+	return makeChan()
+}
+
+func getDeadline() *Deadline { return nil }
+
+func putDeadline(*Deadline) {}
@@ -0,0 +1,40 @@
+//go:build js && !tinygo
+
+package deadline
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSchedulerJS(t *testing.T) {
+	d := new(Deadline)
+	if m := d.scheduler(); m != defaultScheduler {
+		t.Fatalf("deadline is not scheduled by the shared manager")
+	}
+	d.Set(time.Now().Add(time.Hour))
+	if _, ok := defaultScheduler.Next(); !ok {
+		t.Errorf("deadline was not scheduled by the shared manager")
+	}
+	d.Stop()
+
+	d.Set(time.Now().Add(time.Millisecond))
+	select {
+	case <-d.Done():
+	case <-time.After(time.Second):
+		t.Fatalf("deadline was not expired")
+	}
+
+	c := &Deadline{Clock: new(stubClock)}
+	if c.scheduler() != nil {
+		t.Errorf("deadline with own clock is scheduled by the shared manager")
+	}
+}
+
+func TestAcquireNoPoolingJS(t *testing.T) {
+	d := Acquire()
+	Release(d)
+	if Acquire() == d {
+		t.Errorf("released deadline was reused")
+	}
+}
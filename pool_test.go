@@ -0,0 +1,97 @@
+package deadline
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAcquireRelease(t *testing.T) {
+	d := Acquire()
+	d.Name = "request"
+	d.OnExpire(func() {
+		t.Errorf("hook of released deadline was called")
+	})
+	d.Set(time.Now().Add(10 * time.Millisecond))
+	Release(d)
+
+	if d.Name != "" || d.hooks != nil {
+		t.Errorf("released deadline state was not reset")
+	}
+	mustPanic(t, func() { Release(d) })
+	mustPanic(t, func() { d.Set(time.Now()) })
+
+	d = Acquire()
+	_, gen := d.DoneGen()
+	Release(d)
+	d = Acquire()
+	if d.Current(gen) {
+		t.Errorf("generation of released deadline is current")
+	}
+	d.Set(time.Now().Add(time.Millisecond))
+	select {
+	case <-d.Done():
+	case <-time.After(time.Second):
+		t.Fatalf("acquired deadline was not expired")
+	}
+	Release(d)
+}
+
+func TestReleaseExpiring(t *testing.T) {
+	// Release deadlines right when their timers fire to make stale
+	// expirations wait for the lock of released deadline.
+	for i := 0; i < 100; i++ {
+		d := Acquire()
+		d.Clock = RealClock // Use own timer.
+		d.Set(time.Now().Add(time.Millisecond))
+		time.Sleep(time.Millisecond)
+		Release(d)
+	}
+	d := Acquire()
+	d.Set(time.Now().Add(time.Millisecond))
+	select {
+	case <-d.Done():
+	case <-time.After(time.Second):
+		t.Fatalf("acquired deadline was not expired")
+	}
+	Release(d)
+}
+
+func TestEnablePooling(t *testing.T) {
+	EnablePooling(false)
+	defer EnablePooling(true)
+
+	d := Acquire()
+	Release(d)
+	if x := Acquire(); x == d {
+		t.Errorf("released deadline was reused while pooling is disabled")
+	}
+}
+
+func TestPreallocate(t *testing.T) {
+	before := ReadPoolStats()
+	alloc := Preallocate(4)
+	if n := ReadPoolStats().Channels - before.Channels; n != 4 {
+		t.Fatalf("preallocated %d channels; want 4", n)
+	}
+	d := Deadline{Alloc: alloc}
+	seen := make(map[<-chan struct{}]bool)
+	for i := 0; i < 6; i++ {
+		d.Set(time.Now())
+		done := d.Done()
+		<-done
+		if seen[done] {
+			t.Fatalf("done channel was reused")
+		}
+		seen[done] = true
+	}
+}
+
+func mustPanic(t *testing.T, fn func()) {
+	t.Helper()
+	defer func() {
+		if recover() == nil {
+			t.Errorf("no panic")
+		}
+	}()
+	fn()
+}
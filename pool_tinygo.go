@@ -0,0 +1,18 @@
+//go:build tinygo
+
+package deadline
+
+// defaultScheduler is a Manager which schedules expiration of the Deadlines
+// which have no Manager. If nil, Deadlines use their own timers.
+var defaultScheduler *Manager
+
+// Pooling is disabled under TinyGo to not depend on sync.Pool.
+
+func acquireDone() chan struct{} {
+	count(&poolStats.DoneMisses)
+	return makeChan()
+}
+
+func getDeadline() *Deadline { return nil }
+
+func putDeadline(*Deadline) {}
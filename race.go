@@ -0,0 +1,100 @@
+package deadline
+
+import (
+	"sync"
+	"time"
+)
+
+// DoAny runs callbacks in parallel under the deadline d and returns the
+// result of the first one which succeeds. Callbacks receive a stop channel,
+// which is closed when DoAny() returns, so the losers may stop their work.
+//
+// If all callbacks fail, DoAny() returns the error of the last failed one.
+// If the deadline expires first, it returns the expiration reason
+// (ErrDeadline or the like). Callbacks are started with the Goer (or
+// RejectGoer) of d; callback dropped by the RejectGoer fails with
+// ErrRejected.
+func (d *Deadline) DoAny(cbs ...func(stop <-chan struct{}) (interface{}, error)) (interface{}, error) {
+	return d.DoHedged(0, cbs...)
+}
+
+// DoHedged is like DoAny() but starts callbacks one after another, each one
+// after the stagger delay since the previous one was started, or right after
+// the previous one failed. If stagger is zero, all callbacks are started at
+// once.
+//
+// It is intended for the hedged requests mitigating the tail latency: next
+// replica is asked only if the previous one did not respond in time.
+func (d *Deadline) DoHedged(stagger time.Duration, cbs ...func(stop <-chan struct{}) (interface{}, error)) (interface{}, error) {
+	if len(cbs) == 0 {
+		panic("deadline: no callbacks given")
+	}
+	type result struct {
+		v   interface{}
+		err error
+	}
+	var (
+		done    = d.Done()
+		stop    = make(chan struct{})
+		results = make(chan result, len(cbs))
+		timer   Timer
+		tick    <-chan time.Time
+		next    int
+		pending int
+		last    error
+	)
+	defer close(stop)
+	if stagger > 0 {
+		timer = d.clock().NewTimer(stagger)
+		defer timer.Stop()
+		tick = timer.Chan()
+	}
+	launch := func() {
+		cb := cbs[next]
+		next++
+		pending++
+		var once sync.Once
+		d.start(done, func() {
+			v, err := cb(stop)
+			results <- result{v, err}
+		}, func() {
+			once.Do(func() {
+				results <- result{nil, ErrRejected}
+			})
+		})
+		if timer != nil {
+			if !timer.Stop() {
+				select {
+				case <-tick:
+				default:
+				}
+			}
+			timer.Reset(stagger)
+		}
+	}
+	launch()
+	for stagger == 0 && next < len(cbs) {
+		launch()
+	}
+	for {
+		select {
+		case r := <-results:
+			if r.err == nil {
+				return r.v, nil
+			}
+			last = r.err
+			pending--
+			if next < len(cbs) {
+				launch()
+			} else if pending == 0 {
+				return nil, last
+			}
+		case <-tick:
+			if next < len(cbs) {
+				launch()
+			}
+		case <-done:
+			return nil, d.expired()
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package deadline
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDeadlineDoAny(t *testing.T) {
+	var d Deadline
+	d.SetTimeout(time.Second)
+
+	stopped := make(chan struct{})
+	v, err := d.DoAny(
+		func(stop <-chan struct{}) (interface{}, error) {
+			<-stop
+			close(stopped)
+			return nil, nil
+		},
+		func(<-chan struct{}) (interface{}, error) {
+			return nil, errors.New("failed")
+		},
+		func(<-chan struct{}) (interface{}, error) {
+			time.Sleep(5 * time.Millisecond)
+			return "ok", nil
+		},
+	)
+	if err != nil || v != "ok" {
+		t.Fatalf("DoAny() = %v, %v; want ok", v, err)
+	}
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatalf("loser was not stopped")
+	}
+
+	errLast := errors.New("last")
+	_, err = d.DoAny(
+		func(<-chan struct{}) (interface{}, error) {
+			return nil, errors.New("first")
+		},
+		func(<-chan struct{}) (interface{}, error) {
+			time.Sleep(5 * time.Millisecond)
+			return nil, errLast
+		},
+	)
+	if err != errLast {
+		t.Fatalf("unexpected error: %v; want %v", err, errLast)
+	}
+
+	d.SetTimeout(10 * time.Millisecond)
+	_, err = d.DoAny(func(stop <-chan struct{}) (interface{}, error) {
+		<-stop
+		return nil, nil
+	})
+	if err != ErrDeadline {
+		t.Fatalf("unexpected error: %v; want %v", err, ErrDeadline)
+	}
+}
+
+func TestDeadlineDoHedged(t *testing.T) {
+	var d Deadline
+	d.SetTimeout(time.Second)
+
+	started := make(chan int, 3)
+	slow := func(i int) func(<-chan struct{}) (interface{}, error) {
+		return func(stop <-chan struct{}) (interface{}, error) {
+			started <- i
+			<-stop
+			return nil, nil
+		}
+	}
+	v, err := d.DoHedged(20*time.Millisecond,
+		slow(0),
+		func(<-chan struct{}) (interface{}, error) {
+			started <- 1
+			return 1, nil
+		},
+		slow(2),
+	)
+	if err != nil || v != 1 {
+		t.Fatalf("DoHedged() = %v, %v; want 1", v, err)
+	}
+	if n := len(started); n != 2 {
+		t.Errorf("started %d callbacks; want 2", n)
+	}
+}
+
+func TestDeadlineDoHedgedRejected(t *testing.T) {
+	p := NewWorkerPool(1, 0)
+	p.Overflow = OverflowReject
+	release := make(chan struct{})
+	defer close(release)
+	p.Go(nil, func() { <-release })
+
+	d := Deadline{RejectGoer: p.TryGo}
+	d.SetTimeout(time.Hour)
+	_, err := d.DoHedged(time.Millisecond,
+		func(<-chan struct{}) (interface{}, error) { return nil, nil },
+		func(<-chan struct{}) (interface{}, error) { return nil, nil },
+	)
+	if err != ErrRejected {
+		t.Errorf("unexpected error: %v; want %v", err, ErrRejected)
+	}
+}
@@ -0,0 +1,152 @@
+package deadline
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Registered describes armed Deadline listed by the debug registry.
+type Registered struct {
+	Name    string
+	Tags    []string
+	Expires time.Time
+
+	// Stack is the stack of the goroutine which armed the deadline.
+	Stack []byte
+}
+
+// AbandonedCall describes Do() callback which is still running after Do()
+// returned due to the deadline expiration.
+type AbandonedCall struct {
+	Name     string
+	Deadline time.Time
+
+	// Stack is the stack of the Do() caller at the moment of return.
+	Stack []byte
+}
+
+var registry struct {
+	enabled   int32
+	mu        sync.Mutex
+	live      map[*Deadline]*Registered
+	abandoned map[*lateTracker]*AbandonedCall
+}
+
+// EnableRegistry enables or disables the debug registry of armed deadlines
+// and abandoned callbacks. When enabled, every Set() which arms a deadline
+// captures the stack of the calling goroutine, so the registry must be used
+// for debugging only.
+//
+// Disabling the registry drops all registered deadlines and callbacks.
+func EnableRegistry(enable bool) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	if enable {
+		atomic.StoreInt32(&registry.enabled, 1)
+		return
+	}
+	atomic.StoreInt32(&registry.enabled, 0)
+	registry.live = nil
+	registry.abandoned = nil
+}
+
+// Armed returns deadlines which are armed and not expired yet, ordered by
+// their expiration time. It returns nil if the registry is not enabled (see
+// EnableRegistry()).
+func Armed() []Registered {
+	registry.mu.Lock()
+	ret := make([]Registered, 0, len(registry.live))
+	for _, r := range registry.live {
+		ret = append(ret, *r)
+	}
+	registry.mu.Unlock()
+	sort.Sort(byExpires(ret))
+	return ret
+}
+
+func registryEnabled() bool {
+	return atomic.LoadInt32(&registry.enabled) == 1
+}
+
+// register adds d to the registry. It must be called with d.mu held right
+// after d is armed.
+func (d *Deadline) register() {
+	if !registryEnabled() {
+		return
+	}
+	r := &Registered{
+		Name:    d.Name,
+		Tags:    d.Tags,
+		Expires: d.at,
+		Stack:   stack(false),
+	}
+	registry.mu.Lock()
+	if registryEnabled() {
+		if registry.live == nil {
+			registry.live = make(map[*Deadline]*Registered)
+		}
+		registry.live[d] = r
+	}
+	registry.mu.Unlock()
+}
+
+// unregister removes d from the registry. It must be called with d.mu held.
+func (d *Deadline) unregister() {
+	if !registryEnabled() {
+		return
+	}
+	registry.mu.Lock()
+	delete(registry.live, d)
+	registry.mu.Unlock()
+}
+
+// Abandoned returns Do() callbacks which are still running after Do()
+// returned due to the deadline expiration. It returns nil if the registry is
+// not enabled (see EnableRegistry()). Only callbacks of Do() calls made while
+// the registry is enabled are listed.
+func Abandoned() []AbandonedCall {
+	registry.mu.Lock()
+	ret := make([]AbandonedCall, 0, len(registry.abandoned))
+	for _, c := range registry.abandoned {
+		ret = append(ret, *c)
+	}
+	registry.mu.Unlock()
+	sort.Sort(byDeadline(ret))
+	return ret
+}
+
+func registerAbandoned(l *lateTracker, name string, at time.Time) {
+	c := &AbandonedCall{
+		Name:     name,
+		Deadline: at,
+		Stack:    stack(false),
+	}
+	registry.mu.Lock()
+	if registryEnabled() {
+		if registry.abandoned == nil {
+			registry.abandoned = make(map[*lateTracker]*AbandonedCall)
+		}
+		registry.abandoned[l] = c
+	}
+	registry.mu.Unlock()
+}
+
+func unregisterAbandoned(l *lateTracker) {
+	registry.mu.Lock()
+	delete(registry.abandoned, l)
+	registry.mu.Unlock()
+}
+
+type byExpires []Registered
+
+func (r byExpires) Len() int           { return len(r) }
+func (r byExpires) Less(i, j int) bool { return r[i].Expires.Before(r[j].Expires) }
+func (r byExpires) Swap(i, j int)      { r[i], r[j] = r[j], r[i] }
+
+type byDeadline []AbandonedCall
+
+func (c byDeadline) Len() int           { return len(c) }
+func (c byDeadline) Less(i, j int) bool { return c[i].Deadline.Before(c[j].Deadline) }
+func (c byDeadline) Swap(i, j int)      { c[i], c[j] = c[j], c[i] }
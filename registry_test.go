@@ -0,0 +1,33 @@
+package deadline
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestRegistry(t *testing.T) {
+	EnableRegistry(true)
+	defer EnableRegistry(false)
+
+	d := Deadline{Name: "leaked"}
+	d.Set(time.Now().Add(time.Hour))
+	var found *Registered
+	for _, r := range Armed() {
+		if r.Name == "leaked" {
+			found = &r
+		}
+	}
+	if found == nil {
+		t.Fatalf("armed deadline is not registered")
+	}
+	if stack(false) != nil && !bytes.Contains(found.Stack, []byte("TestRegistry")) {
+		t.Errorf("unexpected arming stack:\n%s", found.Stack)
+	}
+	d.Stop()
+	for _, r := range Armed() {
+		if r.Name == "leaked" {
+			t.Fatalf("stopped deadline is still registered")
+		}
+	}
+}
@@ -0,0 +1,275 @@
+package deadline
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// Backoff describes how Retry() makes attempts.
+type Backoff struct {
+	// Attempt is a timeout of a single attempt. Each attempt is given with
+	// the minimum of Attempt and the time remaining until the deadline. If
+	// zero, attempt is bounded only by the deadline.
+	Attempt time.Duration
+
+	// Delay is a pause between attempts.
+	Delay time.Duration
+
+	// Multiplier is a factor the Delay is multiplied by after every failed
+	// attempt, which makes the backoff exponential. Values less than or
+	// equal to one keep the Delay constant.
+	Multiplier float64
+
+	// MaxDelay limits the Delay growth. If zero, Delay is not limited.
+	MaxDelay time.Duration
+
+	// Jitter is a fraction of the Delay which is randomly subtracted from it
+	// to spread attempts of concurrent clients. It must be in range [0, 1].
+	Jitter float64
+
+	// MinAttempt is the time an attempt needs to finish. Retry() never
+	// starts an attempt (nor sleeps before it) when less time remains until
+	// the deadline.
+	MinAttempt time.Duration
+
+	// Retryable reports whether the attempt error is transient. If it is
+	// not nil and returns false, Retry() returns the error as is. If nil,
+	// all errors are retried.
+	Retryable func(error) bool
+}
+
+// delay returns the pause before the next attempt given the current delay.
+func (b Backoff) delay(cur time.Duration) time.Duration {
+	if b.Jitter > 0 {
+		cur -= time.Duration(b.Jitter * rand.Float64() * float64(cur))
+	}
+	return cur
+}
+
+// next returns the delay following cur.
+func (b Backoff) next(cur time.Duration) time.Duration {
+	if b.Multiplier > 1 {
+		cur = time.Duration(float64(cur) * b.Multiplier)
+	}
+	if b.MaxDelay > 0 && cur > b.MaxDelay {
+		cur = b.MaxDelay
+	}
+	return cur
+}
+
+// Bound describes what interrupted an attempt made by Retry().
+type Bound int
+
+const (
+	// BoundNone means that attempt was not interrupted and failed by itself.
+	BoundNone Bound = iota
+	// BoundTotal means that attempt was interrupted by the deadline.
+	BoundTotal
+	// BoundAttempt means that attempt was interrupted by Backoff.Attempt
+	// timeout.
+	BoundAttempt
+)
+
+func (b Bound) String() string {
+	switch b {
+	case BoundNone:
+		return "none"
+	case BoundTotal:
+		return "total"
+	case BoundAttempt:
+		return "attempt"
+	default:
+		return "unknown"
+	}
+}
+
+// RetryError is returned by Retry() when the deadline expires before any of
+// the attempts succeeds. It unwraps to the ErrDeadline.
+type RetryError struct {
+	// Attempts is the number of made attempts.
+	Attempts int
+
+	// Bound describes what interrupted the last attempt.
+	Bound Bound
+
+	// Err is the error of the last attempt, if it failed by itself.
+	Err error
+}
+
+func (e *RetryError) Error() string {
+	s := fmt.Sprintf(
+		"%s after %d attempt(s); last attempt bound: %s",
+		ErrDeadline.Error(), e.Attempts, e.Bound,
+	)
+	if e.Err != nil {
+		s += "; last error: " + e.Err.Error()
+	}
+	return s
+}
+
+func (e *RetryError) Unwrap() error   { return ErrDeadline }
+func (e *RetryError) Timeout() bool   { return true }
+func (e *RetryError) Temporary() bool { return true }
+
+var errAttemptTimeout = errors.New("attempt timeout")
+
+// Retry calls op under the deadline d until it returns nil error or until
+// the deadline expires. In latter case it returns *RetryError, or the reason
+// given to Cancel() if d was canceled. It also
+// returns *RetryError without waiting for the expiration when the remaining
+// time is less than b.MinAttempt. Note that if d is not set, Retry() may
+// never return.
+func Retry(d *Deadline, b Backoff, op func() error) error {
+	var (
+		last  RetryError
+		delay = b.Delay
+	)
+	for {
+		if b.MinAttempt > 0 && !fits(d, b.MinAttempt) {
+			if last.Attempts == 0 {
+				last.Bound = BoundTotal
+			}
+			return &last
+		}
+		var (
+			timeout = b.Attempt
+			bound   = BoundAttempt
+		)
+		if at := d.Expires(); !at.IsZero() {
+			if rem := at.Sub(d.now()); timeout == 0 || rem <= timeout {
+				timeout = rem
+				bound = BoundTotal
+			}
+		}
+		var opts []Option
+		if timeout > 0 || bound == BoundTotal {
+			opts = append(opts,
+				WithDeadline(d.now().Add(timeout)),
+				WithError(errAttemptTimeout),
+			)
+		}
+		var err error
+		last.Attempts++
+		switch derr := d.Do(func() { err = op() }, opts...); {
+		case derr == nil && err == nil:
+			return nil
+
+		case derr == nil:
+			if b.Retryable != nil && !b.Retryable(err) {
+				return err
+			}
+			last.Bound = BoundNone
+			last.Err = err
+
+		case isExpired(d):
+			if err := d.canceled(); err != nil {
+				return err
+			}
+			last.Bound = BoundTotal
+			last.Err = nil
+
+		default:
+			last.Bound = bound
+			last.Err = nil
+		}
+		pause := b.delay(delay)
+		if b.MinAttempt > 0 && !fits(d, pause+b.MinAttempt) {
+			// Next attempt could not finish in time anyway.
+			return &last
+		}
+		if !sleep(d, pause) {
+			if err := d.canceled(); err != nil {
+				return err
+			}
+			return &last
+		}
+		delay = b.next(delay)
+	}
+}
+
+// fits reports whether at least dur remains until d's deadline.
+func fits(d *Deadline, dur time.Duration) bool {
+	at := d.Expires()
+	return at.IsZero() || at.Sub(d.now()) >= dur
+}
+
+// DoTries runs cb under the deadline d up to n times, until it returns nil
+// error. Attempts are numbered from one. It returns nil if some attempt
+// succeeds, the error of the last attempt if all of them failed, or the
+// error returned by Do() (ErrDeadline or the like) if the deadline expires
+// first. Given options are applied to each attempt's Do() call.
+//
+// It is intended for idempotent operations which do not need a full retry
+// policy (see Retry()).
+func (d *Deadline) DoTries(n int, cb func(attempt int) error, opts ...Option) error {
+	var err error
+	for i := 1; i <= n; i++ {
+		if derr := d.Do(func() { err = cb(i) }, opts...); derr != nil {
+			return derr
+		}
+		if err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// Sleep pauses current goroutine for the given duration. It returns early if
+// the deadline d expires during the sleep (or is already expired). In that
+// case it returns the expiration reason as reported by d's Err(), which is
+// ErrDeadline unless d was canceled.
+func Sleep(d *Deadline, dur time.Duration) error {
+	if sleep(d, dur) {
+		return nil
+	}
+	return d.expired()
+}
+
+// expired returns the reason of d's expiration. It must be called after d's
+// expiration was observed.
+func (d *Deadline) expired() error {
+	if err := d.Err(); err != nil {
+		return err
+	}
+	// Deadline was re-armed right after the expiration.
+	return ErrDeadline
+}
+
+// canceled returns the reason of d's cancelation. It returns nil if d is not
+// expired or is expired by the deadline point.
+func (d *Deadline) canceled() error {
+	if err := d.Err(); err != ErrDeadline {
+		return err
+	}
+	return nil
+}
+
+// sleep pauses current goroutine for the given duration. It returns false if
+// deadline d expires earlier.
+func sleep(d *Deadline, dur time.Duration) bool {
+	if isExpired(d) {
+		return false
+	}
+	if dur <= 0 {
+		return true
+	}
+	t := d.clock().NewTimer(dur)
+	defer t.Stop()
+	select {
+	case <-t.Chan():
+		return true
+	case <-d.Done():
+		return false
+	}
+}
+
+func isExpired(d *Deadline) bool {
+	select {
+	case <-d.Done():
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,212 @@
+package deadline
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRetry(t *testing.T) {
+	var d Deadline
+	d.Set(time.Now().Add(time.Second))
+	var n int
+	err := Retry(&d, Backoff{}, func() error {
+		if n++; n < 3 {
+			return errors.New("transient")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n != 3 {
+		t.Errorf("unexpected number of attempts: %d; want 3", n)
+	}
+}
+
+func TestRetryCanceled(t *testing.T) {
+	errStop := errors.New("stop")
+	for _, pause := range []time.Duration{0, time.Hour} {
+		var d Deadline
+		d.Set(time.Now().Add(time.Hour))
+		err := Retry(&d, Backoff{Delay: pause}, func() error {
+			if pause == 0 {
+				d.Cancel(errStop)
+				time.Sleep(10 * time.Millisecond)
+				return nil
+			}
+			go func() {
+				time.Sleep(10 * time.Millisecond)
+				d.Cancel(errStop)
+			}()
+			return errors.New("transient")
+		})
+		if !errors.Is(err, errStop) || IsTimeout(err) {
+			t.Errorf("unexpected error with %s pause: %v; want %v", pause, err, errStop)
+		}
+	}
+}
+
+func TestRetryBound(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	for _, test := range []struct {
+		name  string
+		total time.Duration
+		b     Backoff
+		bound Bound
+	}{
+		{
+			name:  "total",
+			total: 30 * time.Millisecond,
+			b:     Backoff{Attempt: time.Second},
+			bound: BoundTotal,
+		},
+		{
+			name:  "attempt",
+			total: 30 * time.Millisecond,
+			b: Backoff{
+				Attempt: 5 * time.Millisecond,
+				Delay:   50 * time.Millisecond,
+			},
+			bound: BoundAttempt,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var d Deadline
+			d.Set(time.Now().Add(test.total))
+			err := Retry(&d, test.b, func() error {
+				<-release
+				return nil
+			})
+			e, ok := err.(*RetryError)
+			if !ok {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if e.Bound != test.bound {
+				t.Errorf("unexpected bound: %s; want %s", e.Bound, test.bound)
+			}
+			if !errors.Is(err, ErrDeadline) {
+				t.Errorf("error does not match ErrDeadline")
+			}
+		})
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	var d Deadline
+	d.Set(time.Now().Add(time.Second))
+	var times []time.Time
+	err := Retry(&d, Backoff{
+		Delay:      5 * time.Millisecond,
+		Multiplier: 2,
+		MaxDelay:   20 * time.Millisecond,
+	}, func() error {
+		if times = append(times, time.Now()); len(times) < 5 {
+			return errors.New("transient")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i, min := range []time.Duration{5, 10, 20, 20} {
+		min *= time.Millisecond
+		if pause := times[i+1].Sub(times[i]); pause < min {
+			t.Errorf("pause #%d is %s; want at least %s", i, pause, min)
+		}
+	}
+
+	errFatal := errors.New("fatal")
+	err = Retry(&d, Backoff{
+		Retryable: func(err error) bool { return err != errFatal },
+	}, func() error {
+		return errFatal
+	})
+	if err != errFatal {
+		t.Errorf("unexpected error: %v; want %v", err, errFatal)
+	}
+}
+
+func TestRetryMinAttempt(t *testing.T) {
+	var d Deadline
+	d.Set(time.Now().Add(100 * time.Millisecond))
+	var n int
+	start := time.Now()
+	err := Retry(&d, Backoff{
+		Delay:      60 * time.Millisecond,
+		MinAttempt: 50 * time.Millisecond,
+	}, func() error {
+		n++
+		return errors.New("transient")
+	})
+	if _, ok := err.(*RetryError); !ok {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("made %d attempts; want 1", n)
+	}
+	if took := time.Since(start); took > 50*time.Millisecond {
+		t.Errorf("Retry() waited for %s instead of giving up", took)
+	}
+}
+
+func TestSleep(t *testing.T) {
+	var d Deadline
+	if err := Sleep(&d, time.Millisecond); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	d.Set(time.Now().Add(10 * time.Millisecond))
+	start := time.Now()
+	if err := Sleep(&d, time.Second); err != ErrDeadline {
+		t.Fatalf("unexpected error: %v; want %v", err, ErrDeadline)
+	}
+	if took := time.Since(start); took > 500*time.Millisecond {
+		t.Errorf("sleep was not cut short: %s", took)
+	}
+	if err := Sleep(&d, 0); err != ErrDeadline {
+		t.Errorf("unexpected error: %v; want %v", err, ErrDeadline)
+	}
+}
+
+func TestDeadlineDoTries(t *testing.T) {
+	var d Deadline
+	d.SetTimeout(time.Second)
+
+	errLast := errors.New("last")
+	var attempts []int
+	err := d.DoTries(3, func(attempt int) error {
+		attempts = append(attempts, attempt)
+		if attempt == 3 {
+			return errLast
+		}
+		return errors.New("transient")
+	})
+	if err != errLast {
+		t.Fatalf("unexpected error: %v; want %v", err, errLast)
+	}
+	if len(attempts) != 3 || attempts[0] != 1 || attempts[2] != 3 {
+		t.Fatalf("unexpected attempts: %v", attempts)
+	}
+
+	err = d.DoTries(3, func(attempt int) error {
+		if attempt < 2 {
+			return errors.New("transient")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	d.SetTimeout(10 * time.Millisecond)
+	release := make(chan struct{})
+	defer close(release)
+	err = d.DoTries(3, func(int) error {
+		<-release
+		return nil
+	})
+	if err != ErrDeadline {
+		t.Fatalf("unexpected error: %v; want %v", err, ErrDeadline)
+	}
+}
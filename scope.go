@@ -0,0 +1,126 @@
+package deadline
+
+import (
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// ScopeError is returned by Scope's Close() when some of the scope
+// goroutines panicked or did not finish in time.
+type ScopeError struct {
+	// Panics contains panics of the scope goroutines.
+	Panics []*PanicError
+
+	// Running is the number of goroutines which were still running when
+	// Close() returned.
+	Running int
+}
+
+func (e *ScopeError) Error() string {
+	return fmt.Sprintf(
+		"deadline: scope closed with %d panic(s) and %d running goroutine(s)",
+		len(e.Panics), e.Running,
+	)
+}
+
+// Timeout reports whether some goroutines did not finish in time.
+func (e *ScopeError) Timeout() bool { return e.Running > 0 }
+
+// Scope is a structured alternative to the loose go statements in the
+// deadline bounded code. Goroutines spawned by Go() inherit the scope
+// deadline, their panics are recovered and collected, and Close() does not
+// return until all of them are finished or drain time is over.
+//
+// Scope deadline is a child of the given Deadline (see NewChild()).
+type Scope struct {
+	d  *Deadline
+	wg sync.WaitGroup
+
+	mu      sync.Mutex
+	closed  bool
+	running int
+	panics  []*PanicError
+}
+
+// NewScope returns new Scope bound to the deadline d.
+func NewScope(d *Deadline) *Scope {
+	return &Scope{
+		d: NewChild(d),
+	}
+}
+
+// Deadline returns the scope deadline.
+func (s *Scope) Deadline() *Deadline {
+	return s.d
+}
+
+// Go runs fn in a new goroutine, passing it the scope deadline. It panics if
+// the scope is closed.
+func (s *Scope) Go(fn func(*Deadline)) {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		panic("deadline: Go() on closed Scope")
+	}
+	s.running++
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer func() {
+			v := recover()
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			s.running--
+			if v != nil {
+				s.panics = append(s.panics, &PanicError{
+					Value: v,
+					Stack: debug.Stack(),
+				})
+			}
+		}()
+		fn(s.d)
+	}()
+}
+
+// Close waits for the scope goroutines to finish. If the scope deadline
+// expires before that, Close() gives them drain more time to wind down and
+// then returns. It returns *ScopeError if some goroutines panicked or are
+// still running. Scope deadline is canceled after Close() returns.
+func (s *Scope) Close(drain time.Duration) error {
+	s.mu.Lock()
+	s.closed = true
+	s.mu.Unlock()
+
+	all := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(all)
+	}()
+	select {
+	case <-all:
+	case <-s.d.Done():
+		t := s.d.clock().NewTimer(drain)
+		select {
+		case <-all:
+		case <-t.Chan():
+		}
+		t.Stop()
+	}
+	if s.d.Err() == nil {
+		s.d.Cancel(nil)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running == 0 && len(s.panics) == 0 {
+		return nil
+	}
+	return &ScopeError{
+		Panics:  s.panics,
+		Running: s.running,
+	}
+}
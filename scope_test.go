@@ -0,0 +1,49 @@
+package deadline
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScope(t *testing.T) {
+	var d Deadline
+	d.SetTimeout(time.Second)
+
+	s := NewScope(&d)
+	finished := make(chan struct{}, 2)
+	s.Go(func(*Deadline) {
+		time.Sleep(10 * time.Millisecond)
+		finished <- struct{}{}
+	})
+	s.Go(func(*Deadline) {
+		panic("boom")
+	})
+	err := s.Close(0)
+	e, ok := err.(*ScopeError)
+	if !ok {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(e.Panics) != 1 || e.Panics[0].Value != "boom" || e.Running != 0 {
+		t.Fatalf("unexpected scope error: %+v", e)
+	}
+	if len(finished) != 1 {
+		t.Fatalf("Close() returned before goroutine finished")
+	}
+	mustPanic(t, func() { s.Go(func(*Deadline) {}) })
+
+	d.SetTimeout(10 * time.Millisecond)
+	s = NewScope(&d)
+	release := make(chan struct{})
+	defer close(release)
+	s.Go(func(sd *Deadline) {
+		<-sd.Done()
+		time.Sleep(5 * time.Millisecond)
+	})
+	s.Go(func(*Deadline) {
+		<-release
+	})
+	err = s.Close(50 * time.Millisecond)
+	if e, ok := err.(*ScopeError); !ok || e.Running != 1 || !IsTimeout(err) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,40 @@
+//go:build !tinygo
+
+package deadline
+
+import (
+	"bytes"
+	"runtime"
+	"strconv"
+)
+
+// stack returns formatted stack trace of the current goroutine or of all
+// goroutines if all is true.
+func stack(all bool) []byte {
+	buf := make([]byte, 4096)
+	for {
+		n := runtime.Stack(buf, all)
+		if n < len(buf) {
+			return buf[:n]
+		}
+		buf = make([]byte, len(buf)*2)
+	}
+}
+
+var goroutinePrefix = []byte("goroutine ")
+
+// goid returns current goroutine id parsed from its stack header, which looks
+// like "goroutine 42 [running]:".
+func goid() uint64 {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	b = bytes.TrimPrefix(b, goroutinePrefix)
+	if i := bytes.IndexByte(b, ' '); i > 0 {
+		b = b[:i]
+	}
+	id, err := strconv.ParseUint(string(b), 10, 64)
+	if err != nil {
+		panic("deadline: can not parse goroutine id: " + err.Error())
+	}
+	return id
+}
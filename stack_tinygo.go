@@ -0,0 +1,12 @@
+//go:build tinygo
+
+package deadline
+
+// TinyGo does not provide goroutine stack traces, so stack related
+// diagnostics (Debug self-wait checks, CaptureStack, Manager.Dump stacks) are
+// disabled.
+
+func stack(bool) []byte { return nil }
+
+// goid returns zero which means that goroutine id is unknown.
+func goid() uint64 { return 0 }
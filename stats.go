@@ -0,0 +1,92 @@
+package deadline
+
+import "time"
+
+// Stats contains statistics of the Deadline collected when its CollectStats
+// field is true.
+type Stats struct {
+	// Sets is the number of Set() and Clear() calls.
+	Sets uint64
+
+	// Expirations is the number of the deadline expirations, including the
+	// canceled ones.
+	Expirations uint64
+
+	// Cancels is the number of expirations caused by Cancel() or other
+	// expiration with explicit reason.
+	Cancels uint64
+
+	// Completions is the number of Do() callbacks which returned before the
+	// deadline expiration.
+	Completions uint64
+
+	// Abandoned is the number of callbacks which are still running after Do()
+	// returned due to the expiration.
+	Abandoned int64
+
+	// LateCompletions is the number of abandoned callbacks which eventually
+	// returned.
+	LateCompletions uint64
+
+	// MinLatency, MaxLatency and AvgLatency describe durations of the
+	// completed callbacks.
+	MinLatency time.Duration
+	MaxLatency time.Duration
+	AvgLatency time.Duration
+}
+
+type stats struct {
+	Stats
+	total time.Duration
+}
+
+// record accounts event e. It must be called with d.mu held.
+func (s *stats) record(e *Event) {
+	switch e.Type {
+	case EventSet:
+		s.Sets++
+	case EventCancel:
+		s.Cancels++
+		s.Expirations++
+	case EventExpire:
+		s.Expirations++
+	case EventComplete:
+		s.Completions++
+		if s.Completions == 1 || e.Took < s.MinLatency {
+			s.MinLatency = e.Took
+		}
+		if e.Took > s.MaxLatency {
+			s.MaxLatency = e.Took
+		}
+		s.total += e.Took
+		s.AvgLatency = s.total / time.Duration(s.Completions)
+	}
+}
+
+// abandon accounts callback abandoned by Do() due to the expiration.
+func (d *Deadline) abandon() {
+	d.mu.Lock()
+	d.stats.Abandoned++
+	d.mu.Unlock()
+}
+
+// lateDone accounts abandoned callback of operation name returned late after
+// the deadline.
+func (d *Deadline) lateDone(name string, late time.Duration) {
+	d.mu.Lock()
+	d.stats.Abandoned--
+	d.stats.LateCompletions++
+	d.mu.Unlock()
+	if d.OnLate != nil {
+		d.OnLate(name, late)
+	}
+}
+
+// Stats returns snapshot of the deadline statistics. It returns zero Stats if
+// CollectStats is false, except abandoned callbacks counters which are also
+// maintained when OnLate is set.
+func (d *Deadline) Stats() Stats {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.stats.Stats
+}
@@ -0,0 +1,60 @@
+package deadline
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDeadlineStats(t *testing.T) {
+	d := Deadline{CollectStats: true}
+	d.Set(time.Now().Add(time.Hour))
+	for _, dur := range []time.Duration{
+		10 * time.Millisecond,
+		30 * time.Millisecond,
+	} {
+		d.Do(func() {
+			time.Sleep(dur)
+		})
+	}
+	d.Cancel(nil)
+	d.Set(time.Now().Add(-time.Second))
+
+	s := d.Stats()
+	if s.Sets != 2 || s.Expirations != 2 || s.Cancels != 1 || s.Completions != 2 {
+		t.Errorf("unexpected counters: %+v", s)
+	}
+	if s.MinLatency < 10*time.Millisecond || s.MinLatency >= 30*time.Millisecond {
+		t.Errorf("unexpected min latency: %s", s.MinLatency)
+	}
+	if s.MaxLatency < 30*time.Millisecond {
+		t.Errorf("unexpected max latency: %s", s.MaxLatency)
+	}
+	if s.AvgLatency < s.MinLatency || s.AvgLatency > s.MaxLatency {
+		t.Errorf("unexpected avg latency: %s", s.AvgLatency)
+	}
+}
+
+func TestDeadlineLateCompletion(t *testing.T) {
+	late := make(chan string, 1)
+	d := Deadline{
+		OnLate: func(name string, _ time.Duration) {
+			late <- name
+		},
+	}
+	d.Set(time.Now().Add(10 * time.Millisecond))
+	release := make(chan struct{})
+	d.Do(func() {
+		<-release
+	}, WithName("slow"))
+	if n := d.Stats().Abandoned; n != 1 {
+		t.Fatalf("unexpected abandoned callbacks: %d; want 1", n)
+	}
+	close(release)
+	if name := <-late; name != "slow" {
+		t.Errorf("unexpected late operation name: %q", name)
+	}
+	s := d.Stats()
+	if s.Abandoned != 0 || s.LateCompletions != 1 {
+		t.Errorf("unexpected counters: %+v", s)
+	}
+}
@@ -0,0 +1,97 @@
+package deadline
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// Phase represents time spent between two stopwatch checkpoints.
+type Phase struct {
+	Label    string
+	Duration time.Duration
+}
+
+// Stopwatch tracks elapsed time and labeled checkpoints. It is intended to
+// be attached to the Deadline to get a phase-by-phase time breakdown when
+// the deadline expires.
+type Stopwatch struct {
+	mu     sync.Mutex
+	start  time.Time
+	last   time.Time
+	phases []Phase
+}
+
+// Start (re)starts the stopwatch dropping all recorded phases.
+func (s *Stopwatch) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reset(time.Now())
+}
+
+func (s *Stopwatch) reset(now time.Time) {
+	s.start = now
+	s.last = now
+	s.phases = s.phases[:0]
+}
+
+// startOnce starts the stopwatch if it was not started yet.
+func (s *Stopwatch) startOnce() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.start.IsZero() {
+		s.reset(time.Now())
+	}
+}
+
+// Checkpoint records a phase with the given label which lasts since the
+// previous checkpoint (or the stopwatch start).
+func (s *Stopwatch) Checkpoint(label string) {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.start.IsZero() {
+		s.reset(now)
+	}
+	s.phases = append(s.phases, Phase{
+		Label:    label,
+		Duration: now.Sub(s.last),
+	})
+	s.last = now
+}
+
+// Elapsed returns time passed since the stopwatch start.
+func (s *Stopwatch) Elapsed() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.start.IsZero() {
+		return 0
+	}
+	return time.Since(s.start)
+}
+
+// Phases returns a copy of recorded phases.
+func (s *Stopwatch) Phases() []Phase {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Phase(nil), s.phases...)
+}
+
+// String returns phase-by-phase time breakdown such as
+// "dns=10ms connect=25ms total=40ms".
+func (s *Stopwatch) String() string {
+	return formatPhases(s.Phases(), s.Elapsed())
+}
+
+func formatPhases(phases []Phase, total time.Duration) string {
+	var sb strings.Builder
+	for _, p := range phases {
+		sb.WriteString(p.Label)
+		sb.WriteByte('=')
+		sb.WriteString(p.Duration.String())
+		sb.WriteByte(' ')
+	}
+	sb.WriteString("total=")
+	sb.WriteString(total.String())
+	return sb.String()
+}
@@ -0,0 +1,37 @@
+package deadline
+
+import (
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestDeadlineStopwatch(t *testing.T) {
+	d := Deadline{
+		Stopwatch: new(Stopwatch),
+	}
+	d.Set(time.Now().Add(30 * time.Millisecond))
+	time.Sleep(5 * time.Millisecond)
+	d.Checkpoint("dns")
+	time.Sleep(5 * time.Millisecond)
+	d.Checkpoint("connect")
+
+	release := make(chan struct{})
+	defer close(release)
+	err := d.Do(func() { <-release })
+
+	e, ok := err.(*ExpireError)
+	if !ok {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := len(e.Phases); n != 2 {
+		t.Fatalf("unexpected number of phases: %d; want 2", n)
+	}
+	if e.Elapsed < 30*time.Millisecond {
+		t.Errorf("unexpected elapsed time: %s", e.Elapsed)
+	}
+	exp := regexp.MustCompile(`^deadline exceeded \(waited \S+; dns=\S+ connect=\S+ total=\S+\)$`)
+	if s := err.Error(); !exp.MatchString(s) {
+		t.Errorf("unexpected error text: %q", s)
+	}
+}
@@ -0,0 +1,51 @@
+package deadline
+
+import "sync/atomic"
+
+// PoolStats contains counters of the package internal allocations. All
+// counters are cumulative since the program start; rates could be computed by
+// the caller from the difference of two snapshots.
+type PoolStats struct {
+	// DoneHits and DoneMisses are the numbers of Done() channels taken from
+	// the internal pool and allocated due to the pool miss. Done channels
+	// are never recycled (see Deadline's DoneGen()), so DoneHits is always
+	// zero.
+	DoneHits   uint64
+	DoneMisses uint64
+
+	// DeadlineHits and DeadlineMisses are the numbers of Acquire() calls
+	// served from the pool and ones allocated new Deadline.
+	DeadlineHits   uint64
+	DeadlineMisses uint64
+
+	// Timers is the number of created per-Deadline timers.
+	Timers uint64
+
+	// Channels is the number of allocated channels, including Done() channel
+	// misses and Do() completion channels.
+	Channels uint64
+}
+
+var poolStats PoolStats
+
+// ReadPoolStats returns snapshot of the internal allocation counters.
+func ReadPoolStats() PoolStats {
+	return PoolStats{
+		DoneHits:       atomic.LoadUint64(&poolStats.DoneHits),
+		DoneMisses:     atomic.LoadUint64(&poolStats.DoneMisses),
+		DeadlineHits:   atomic.LoadUint64(&poolStats.DeadlineHits),
+		DeadlineMisses: atomic.LoadUint64(&poolStats.DeadlineMisses),
+		Timers:         atomic.LoadUint64(&poolStats.Timers),
+		Channels:       atomic.LoadUint64(&poolStats.Channels),
+	}
+}
+
+func count(counter *uint64) {
+	atomic.AddUint64(counter, 1)
+}
+
+// makeChan allocates new channel accounting it in the pool stats.
+func makeChan() chan struct{} {
+	count(&poolStats.Channels)
+	return make(chan struct{})
+}
@@ -0,0 +1,28 @@
+package deadline
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReadPoolStats(t *testing.T) {
+	before := ReadPoolStats()
+
+	var d Deadline
+	d.Set(time.Now().Add(time.Hour))
+	d.Do(func() {})
+	Release(Acquire())
+
+	after := ReadPoolStats()
+	if after.Timers-before.Timers < 1 {
+		t.Errorf("timer creation was not counted")
+	}
+	if after.Channels-before.Channels < 2 {
+		t.Errorf("channel allocations were not counted")
+	}
+	acquired := after.DeadlineHits + after.DeadlineMisses -
+		before.DeadlineHits - before.DeadlineMisses
+	if acquired < 1 {
+		t.Errorf("deadline acquisition was not counted")
+	}
+}
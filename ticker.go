@@ -0,0 +1,89 @@
+package deadline
+
+import (
+	"sync"
+	"time"
+)
+
+// Ticker holds a channel that delivers ticks of a clock at intervals until
+// the associated Deadline expires. It is much like time.Ticker, but channel
+// C is closed when deadline expires or Stop() is called. Ticks made after
+// deadline expiration are never delivered.
+type Ticker struct {
+	C <-chan time.Time
+
+	once sync.Once
+	stop chan struct{}
+}
+
+// NewTicker returns new Ticker bound to the deadline d. Ticks are made by
+// the d's Clock.
+func NewTicker(d *Deadline, interval time.Duration) *Ticker {
+	c := make(chan time.Time)
+	t := &Ticker{
+		C:    c,
+		stop: make(chan struct{}),
+	}
+	go t.run(d.clock(), d.Done(), interval, c)
+	return t
+}
+
+// Stop turns off the ticker and closes its channel.
+func (t *Ticker) Stop() {
+	t.once.Do(func() {
+		close(t.stop)
+	})
+}
+
+func (t *Ticker) run(clock Clock, done <-chan struct{}, interval time.Duration, c chan<- time.Time) {
+	defer close(c)
+	var (
+		next = clock.Now().Add(interval)
+		tm   = clock.NewTimer(interval)
+	)
+	defer tm.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-t.stop:
+			return
+		case now := <-tm.Chan():
+			// Prioritize deadline expiration over the tick.
+			select {
+			case <-done:
+				return
+			default:
+			}
+			select {
+			case c <- now:
+			case <-done:
+				return
+			case <-t.stop:
+				return
+			}
+		}
+		// Drop ticks missed by the slow receiver, as time.Ticker does.
+		now := clock.Now()
+		for !next.After(now) {
+			next = next.Add(interval)
+		}
+		tm.Reset(next.Sub(now))
+	}
+}
+
+// Tick returns a channel delivering ticks at intervals until the time until.
+// Channel is closed once until is reached. It is a convenience wrapper around
+// NewTicker() for poll loops bounded by an end time:
+//
+//	for range deadline.Tick(time.Second, end) {
+//		// Poll.
+//	}
+//
+// Unlike time.Tick(), underlying goroutine and timer are released when until
+// is reached. If until is zero, they are never released.
+func Tick(interval time.Duration, until time.Time) <-chan time.Time {
+	d := new(Deadline)
+	d.Set(until)
+	return NewTicker(d, interval).C
+}
@@ -0,0 +1,42 @@
+package deadline
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTicker(t *testing.T) {
+	var d Deadline
+	d.Set(time.Now().Add(50 * time.Millisecond))
+	tk := NewTicker(&d, 5*time.Millisecond)
+	defer tk.Stop()
+
+	var n int
+	for range tk.C {
+		n++
+	}
+	if n == 0 {
+		t.Errorf("no ticks delivered")
+	}
+	select {
+	case <-d.Done():
+	default:
+		t.Errorf("ticker channel closed before deadline expiration")
+	}
+}
+
+func TestTick(t *testing.T) {
+	var (
+		start = time.Now()
+		n     int
+	)
+	for range Tick(10*time.Millisecond, start.Add(55*time.Millisecond)) {
+		n++
+	}
+	if took := time.Since(start); took < 50*time.Millisecond {
+		t.Errorf("ticks stopped after %s", took)
+	}
+	if n == 0 || n > 5 {
+		t.Errorf("unexpected number of ticks: %d", n)
+	}
+}
@@ -0,0 +1,34 @@
+package deadline
+
+// DoValue runs cb under d as Do() does and returns its result. If the
+// deadline expires before cb returns, it returns zero value and the error
+// returned by Do().
+//
+// Result of the abandoned callback is dropped, so cb is free to finish after
+// DoValue() returned.
+func DoValue[T any](d *Deadline, cb func() (T, error), opts ...Option) (T, error) {
+	var (
+		v     T
+		cbErr error
+	)
+	err := d.Do(func() {
+		v, cbErr = cb()
+	}, opts...)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return v, cbErr
+}
+
+// DoErr is like Do() but returns the error returned by cb when it returns
+// before the deadline expiration.
+func (d *Deadline) DoErr(cb func() error, opts ...Option) error {
+	var cbErr error
+	if err := d.Do(func() {
+		cbErr = cb()
+	}, opts...); err != nil {
+		return err
+	}
+	return cbErr
+}
@@ -0,0 +1,70 @@
+package deadline
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDoValue(t *testing.T) {
+	errCallback := errors.New("callback error")
+	for _, test := range []struct {
+		name   string
+		delay  time.Duration
+		value  int
+		err    error
+		exp    int
+		expErr error
+	}{
+		{
+			name:  "ok",
+			value: 42,
+			exp:   42,
+		},
+		{
+			name:   "error",
+			value:  42,
+			err:    errCallback,
+			exp:    42,
+			expErr: errCallback,
+		},
+		{
+			name:   "deadline",
+			delay:  100 * time.Millisecond,
+			value:  42,
+			expErr: ErrDeadline,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var d Deadline
+			d.Set(time.Now().Add(20 * time.Millisecond))
+			v, err := DoValue(&d, func() (int, error) {
+				time.Sleep(test.delay)
+				return test.value, test.err
+			})
+			if err != test.expErr {
+				t.Errorf("unexpected error: %v; want %v", err, test.expErr)
+			}
+			if v != test.exp {
+				t.Errorf("unexpected value: %d; want %d", v, test.exp)
+			}
+		})
+	}
+}
+
+func TestDeadlineDoErr(t *testing.T) {
+	errCallback := errors.New("callback error")
+	var d Deadline
+	d.Set(time.Now().Add(20 * time.Millisecond))
+	if err := d.DoErr(func() error {
+		return errCallback
+	}); err != errCallback {
+		t.Errorf("unexpected error: %v; want %v", err, errCallback)
+	}
+	if err := d.DoErr(func() error {
+		time.Sleep(100 * time.Millisecond)
+		return errCallback
+	}); err != ErrDeadline {
+		t.Errorf("unexpected error: %v; want %v", err, ErrDeadline)
+	}
+}
@@ -0,0 +1,113 @@
+package deadline
+
+import "time"
+
+// warning represents pre-expiry notification of the deadline.
+type warning struct {
+	margin time.Duration
+	done   chan struct{}
+	hooks  []func()
+	timer  Timer
+	gen    uint64 // Incremented on every (re)arm to invalidate stale timers.
+}
+
+// Before returns a channel which is closed the margin before the deadline
+// expiration. As with Done(), if deadline is re-armed by Set() before the
+// channel is closed, the channel will be closed the margin before the new
+// deadline.
+//
+// It is intended to let protocol layers to gracefully shut down (e.g. send
+// GOAWAY frames) before the connection is cut off by the deadline.
+func (d *Deadline) Before(margin time.Duration) <-chan struct{} {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.warning(margin).done
+}
+
+// OnBefore registers fn to be called every time the deadline is about to
+// expire in the margin. Hooks are called from a separate goroutine.
+func (d *Deadline) OnBefore(margin time.Duration, fn func()) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	w := d.warning(margin)
+	w.hooks = append(w.hooks, fn)
+}
+
+// Warn returns a channel which is closed when the soft deadline is reached,
+// that is, the Soft margin before the deadline point. It is the same as
+// Before(d.Soft).
+//
+// Soft deadline lets services to start shedding work or to report that the
+// operation is nearly out of time before the hard deadline cuts it off.
+func (d *Deadline) Warn() <-chan struct{} {
+	return d.Before(d.Soft)
+}
+
+// OnWarn registers fn to be called every time the soft deadline is reached.
+// It is the same as OnBefore(d.Soft, fn).
+func (d *Deadline) OnWarn(fn func()) {
+	d.OnBefore(d.Soft, fn)
+}
+
+// warning returns warning with given margin, creating and arming it if
+// needed. It must be called with d.mu held.
+func (d *Deadline) warning(margin time.Duration) *warning {
+	for _, w := range d.warnings {
+		if w.margin == margin {
+			return w
+		}
+	}
+	w := &warning{
+		margin: margin,
+		done:   make(chan struct{}),
+	}
+	// Warnings need the actual deadline point on every Set().
+	d.sync()
+	d.warnings = append(d.warnings, w)
+	d.publish()
+	d.armWarning(w)
+	return w
+}
+
+// armWarnings re-arms all warnings according to d.at. It must be called with
+// d.mu held.
+func (d *Deadline) armWarnings() {
+	for _, w := range d.warnings {
+		d.armWarning(w)
+	}
+}
+
+func (d *Deadline) armWarning(w *warning) {
+	w.gen++
+	if w.timer != nil {
+		w.timer.Stop()
+		w.timer = nil
+	}
+	if d.at.IsZero() {
+		return
+	}
+	select {
+	case <-w.done:
+		w.done = make(chan struct{})
+	default:
+	}
+	gen := w.gen
+	w.timer = d.clock().AfterFunc(d.at.Add(-w.margin).Sub(d.now()), func() {
+		d.fireWarning(w, gen)
+	})
+}
+
+func (d *Deadline) fireWarning(w *warning, gen uint64) {
+	d.mu.Lock()
+	if w.gen != gen {
+		// Warning was re-armed after this timer was scheduled.
+		d.mu.Unlock()
+		return
+	}
+	close(w.done)
+	hooks := w.hooks
+	d.mu.Unlock()
+	for _, fn := range hooks {
+		d.callHook(fn)
+	}
+}
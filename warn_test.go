@@ -0,0 +1,56 @@
+package deadline
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDeadlineBefore(t *testing.T) {
+	var d Deadline
+	d.Set(time.Now().Add(time.Hour))
+	warn := d.Before(20 * time.Millisecond)
+	hook := make(chan struct{}, 1)
+	d.OnBefore(20*time.Millisecond, func() {
+		hook <- struct{}{}
+	})
+	// Re-arm deadline: warning must follow it.
+	d.Set(time.Now().Add(40 * time.Millisecond))
+	select {
+	case <-warn:
+	case <-time.After(time.Second):
+		t.Fatalf("warning channel was not closed")
+	}
+	select {
+	case <-d.Done():
+		t.Fatalf("deadline expired earlier than warning")
+	default:
+	}
+	select {
+	case <-hook:
+	case <-time.After(time.Second):
+		t.Fatalf("warning hook was not called")
+	}
+}
+
+func TestDeadlineWarn(t *testing.T) {
+	d := Deadline{
+		Soft: 30 * time.Millisecond,
+	}
+	hook := make(chan time.Time, 1)
+	d.OnWarn(func() {
+		hook <- time.Now()
+	})
+	d.Set(time.Now().Add(40 * time.Millisecond))
+	select {
+	case <-d.Warn():
+	case <-time.After(time.Second):
+		t.Fatalf("soft deadline was not reached")
+	}
+	select {
+	case <-d.Done():
+		t.Fatalf("hard deadline expired together with soft one")
+	default:
+	}
+	<-hook
+	<-d.Done()
+}
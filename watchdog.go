@@ -0,0 +1,98 @@
+package deadline
+
+import (
+	"sync"
+	"time"
+)
+
+// WatchdogStats contains state of the Watchdog.
+type WatchdogStats struct {
+	// Kicks is the number of Kick() calls.
+	Kicks int
+
+	// Missed is the total number of intervals passed without Kick().
+	Missed int
+
+	// Consecutive is the number of intervals passed without Kick() since
+	// the last Kick().
+	Consecutive int
+
+	// LastKick is the time of the last Kick() call.
+	LastKick time.Time
+}
+
+// Watchdog supervises a long-running loop: it must be kicked within an
+// interval, otherwise it calls the expiry action. It is the inverse of Do():
+// instead of bounding a single call, it detects the loop which stopped making
+// progress.
+//
+// Watchdog keeps watching after the miss: action is called for every
+// interval passed without Kick(), with the number of consecutive misses.
+type Watchdog struct {
+	d        Deadline
+	interval time.Duration
+	action   func(missed int)
+
+	mu      sync.Mutex
+	stats   WatchdogStats
+	stopped bool
+}
+
+// NewWatchdog returns new started Watchdog which calls action when interval
+// passes without Kick(). Action is called from the timer goroutine.
+func NewWatchdog(interval time.Duration, action func(missed int)) *Watchdog {
+	w := &Watchdog{
+		interval: interval,
+		action:   action,
+	}
+	w.d.OnExpire(w.miss)
+	w.d.SetTimeout(interval)
+	return w
+}
+
+// Kick reports that the supervised loop is alive. It re-arms the watchdog
+// for the next interval.
+func (w *Watchdog) Kick() {
+	now := time.Now()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.stopped {
+		return
+	}
+	w.stats.Kicks++
+	w.stats.Consecutive = 0
+	w.stats.LastKick = now
+	w.d.Set(now.Add(w.interval))
+}
+
+// Stop stops the watchdog. Action is not called after Stop() returns, unless
+// it is running already.
+func (w *Watchdog) Stop() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.stopped = true
+	w.d.Stop()
+}
+
+// Stats returns current state of the watchdog.
+func (w *Watchdog) Stats() WatchdogStats {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.stats
+}
+
+func (w *Watchdog) miss() {
+	w.mu.Lock()
+	if w.stopped || !isExpired(&w.d) {
+		// Stopped or kicked right after the expiration.
+		w.mu.Unlock()
+		return
+	}
+	w.stats.Missed++
+	w.stats.Consecutive++
+	n := w.stats.Consecutive
+	w.d.SetTimeout(w.interval)
+	w.mu.Unlock()
+
+	w.action(n)
+}
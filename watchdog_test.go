@@ -0,0 +1,38 @@
+package deadline
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWatchdog(t *testing.T) {
+	missed := make(chan int, 10)
+	w := NewWatchdog(20*time.Millisecond, func(n int) {
+		missed <- n
+	})
+	defer w.Stop()
+	for i := 0; i < 5; i++ {
+		time.Sleep(5 * time.Millisecond)
+		w.Kick()
+	}
+	select {
+	case n := <-missed:
+		t.Fatalf("unexpected miss #%d while kicking", n)
+	default:
+	}
+	for exp := 1; exp <= 2; exp++ {
+		select {
+		case n := <-missed:
+			if n != exp {
+				t.Fatalf("unexpected consecutive misses: %d; want %d", n, exp)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("miss was not reported")
+		}
+	}
+	w.Kick()
+	s := w.Stats()
+	if s.Kicks != 6 || s.Missed < 2 || s.Consecutive != 0 {
+		t.Errorf("unexpected stats: %+v", s)
+	}
+}
@@ -0,0 +1,159 @@
+package deadline
+
+import "time"
+
+const (
+	// DefaultWheelSlots is the default number of slots per timing wheel
+	// level.
+	DefaultWheelSlots = 256
+
+	wheelLevels = 4
+)
+
+// wheel is a hierarchical timing wheel. Level 0 slots span single tick, and
+// slots of each next level span the whole previous level. Deadlines are put
+// to the lowest level which covers their expiration tick and are cascaded to
+// the lower levels as time goes. Deadlines beyond the highest level are kept
+// in the overflow bucket until the highest level wraps around.
+//
+// wheel is guarded by the Manager's mutex.
+type wheel struct {
+	tick     time.Duration
+	slots    int64
+	origin   time.Time
+	pos      int64 // Number of the next tick to expire.
+	levels   [wheelLevels][]*bucket
+	overflow *bucket
+	n        int // Number of scheduled deadlines.
+}
+
+func newWheel(tick time.Duration, slots int, now time.Time) *wheel {
+	if slots <= 1 {
+		slots = DefaultWheelSlots
+	}
+	w := &wheel{
+		tick:     tick,
+		slots:    int64(slots),
+		origin:   now,
+		overflow: new(bucket),
+	}
+	for i := range w.levels {
+		w.levels[i] = make([]*bucket, slots)
+		for j := range w.levels[i] {
+			w.levels[i][j] = new(bucket)
+		}
+	}
+	return w
+}
+
+// tickOf returns the number of the first tick at or after t.
+func (w *wheel) tickOf(t time.Time) int64 {
+	n := t.Sub(w.origin)
+	if n <= 0 {
+		return 0
+	}
+	return int64((n + w.tick - 1) / w.tick)
+}
+
+// timeOf returns the time of the tick k.
+func (w *wheel) timeOf(k int64) time.Time {
+	return w.origin.Add(time.Duration(k) * w.tick)
+}
+
+func (w *wheel) add(d *Deadline, t time.Time) {
+	d.tick = w.tickOf(t)
+	w.place(d)
+	w.n++
+}
+
+// place puts d to the slot corresponding to its tick.
+func (w *wheel) place(d *Deadline) {
+	k := d.tick
+	if k < w.pos {
+		k = w.pos
+	}
+	var (
+		delta = k - w.pos
+		span  = int64(1)
+	)
+	for l := 0; l < wheelLevels; l++ {
+		if delta < span*w.slots {
+			w.levels[l][(k/span)%w.slots].push(d)
+			return
+		}
+		span *= w.slots
+	}
+	w.overflow.push(d)
+}
+
+// remove accounts removal of the deadline from some of the wheel buckets.
+func (w *wheel) remove() {
+	w.n--
+}
+
+// advance expires all deadlines which ticks are due at now. Expired
+// deadlines are passed to expire.
+func (w *wheel) advance(now time.Time, expire func(*Deadline)) {
+	target := int64(-1)
+	if n := now.Sub(w.origin); n >= 0 {
+		target = int64(n / w.tick)
+	}
+	for w.pos <= target {
+		if w.n == 0 {
+			w.pos = target + 1
+			return
+		}
+		w.cascade()
+		b := w.levels[0][w.pos%w.slots]
+		for _, d := range b.take() {
+			w.n--
+			expire(d)
+		}
+		w.pos++
+	}
+}
+
+// cascade moves deadlines of the higher levels down when their slots become
+// current.
+func (w *wheel) cascade() {
+	span := int64(1)
+	for l := 1; l < wheelLevels; l++ {
+		span *= w.slots
+		if w.pos%span != 0 {
+			return
+		}
+		if l == wheelLevels-1 {
+			for _, d := range w.overflow.take() {
+				w.place(d)
+			}
+		}
+		for _, d := range w.levels[l][(w.pos/span)%w.slots].take() {
+			w.place(d)
+		}
+	}
+}
+
+// next returns the time of the next tick. It returns false if there are no
+// scheduled deadlines.
+func (w *wheel) next() (time.Time, bool) {
+	if w.n == 0 {
+		return time.Time{}, false
+	}
+	return w.timeOf(w.pos), true
+}
+
+func (b *bucket) push(d *Deadline) {
+	d.bucket = b
+	d.index = len(b.deadlines)
+	b.deadlines = append(b.deadlines, d)
+}
+
+// take removes all deadlines from b and returns them.
+func (b *bucket) take() []*Deadline {
+	ds := b.deadlines
+	b.deadlines = nil
+	for _, d := range ds {
+		d.bucket = nil
+	}
+	return ds
+}
@@ -0,0 +1,89 @@
+package deadline
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// maxTimeoutValue is the maximum number of units in the encoded timeout, as
+// limited by the gRPC wire format to eight digits.
+const maxTimeoutValue = 1e8 - 1
+
+var timeoutUnits = []struct {
+	unit   time.Duration
+	suffix byte
+}{
+	{time.Nanosecond, 'n'},
+	{time.Microsecond, 'u'},
+	{time.Millisecond, 'm'},
+	{time.Second, 'S'},
+	{time.Minute, 'M'},
+	{time.Hour, 'H'},
+}
+
+// FormatTimeout returns timeout encoded in the "grpc-timeout" format, that
+// is, as up to eight digits followed by the unit. The finest unit which fits
+// the timeout is used; value is rounded up. Non-positive timeout is encoded
+// as zero.
+func FormatTimeout(timeout time.Duration) string {
+	if timeout <= 0 {
+		return "0n"
+	}
+	for _, u := range timeoutUnits {
+		n := int64(timeout / u.unit)
+		if timeout%u.unit > 0 {
+			n++
+		}
+		if n <= maxTimeoutValue {
+			return strconv.FormatInt(n, 10) + string(u.suffix)
+		}
+	}
+	return strconv.FormatInt(maxTimeoutValue, 10) + "H"
+}
+
+// ParseTimeout parses timeout encoded by FormatTimeout().
+func ParseTimeout(s string) (time.Duration, error) {
+	if len(s) < 2 || len(s) > 9 {
+		return 0, fmt.Errorf("deadline: malformed timeout %q", s)
+	}
+	n, err := strconv.ParseUint(s[:len(s)-1], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("deadline: malformed timeout %q", s)
+	}
+	for _, u := range timeoutUnits {
+		if u.suffix != s[len(s)-1] {
+			continue
+		}
+		if max := time.Duration(1<<63-1) / u.unit; time.Duration(n) > max {
+			return max * u.unit, nil
+		}
+		return time.Duration(n) * u.unit, nil
+	}
+	return 0, fmt.Errorf("deadline: unknown timeout unit in %q", s)
+}
+
+// EncodeBudget returns d's remaining time encoded by FormatTimeout(). It
+// returns false if d is not set.
+//
+// It is intended for propagation of the deadline to the downstream services,
+// e.g. as the "grpc-timeout" metadata or HTTP header value.
+func (d *Deadline) EncodeBudget() (string, bool) {
+	n := d.Remaining()
+	if n < 0 {
+		return "", false
+	}
+	return FormatTimeout(n), true
+}
+
+// DecodeBudget returns new Deadline set to expire after the timeout encoded
+// in s by EncodeBudget() or FormatTimeout().
+func DecodeBudget(s string) (*Deadline, error) {
+	n, err := ParseTimeout(s)
+	if err != nil {
+		return nil, err
+	}
+	d := new(Deadline)
+	d.SetTimeout(n)
+	return d, nil
+}
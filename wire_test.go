@@ -0,0 +1,60 @@
+package deadline
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormatTimeout(t *testing.T) {
+	for _, test := range []struct {
+		timeout time.Duration
+		exp     string
+	}{
+		{0, "0n"},
+		{-time.Second, "0n"},
+		{10 * time.Millisecond, "10000000n"},
+		{time.Second, "1000000u"},
+		{100 * time.Second, "100000m"},
+		{100*time.Second + 1, "100001m"},
+		{time.Hour, "3600000m"},
+		{1000 * time.Hour, "3600000S"},
+	} {
+		act := FormatTimeout(test.timeout)
+		if act != test.exp {
+			t.Errorf("FormatTimeout(%s) = %q; want %q", test.timeout, act, test.exp)
+			continue
+		}
+		n, err := ParseTimeout(act)
+		if err != nil {
+			t.Errorf("ParseTimeout(%q) error: %v", act, err)
+			continue
+		}
+		if test.timeout > 0 && (n < test.timeout || n-test.timeout >= time.Millisecond) {
+			t.Errorf("ParseTimeout(%q) = %s; want %s", act, n, test.timeout)
+		}
+	}
+	for _, s := range []string{"", "1", "1x", "123456789S", "-1S", "S"} {
+		if _, err := ParseTimeout(s); err == nil {
+			t.Errorf("ParseTimeout(%q): expected error", s)
+		}
+	}
+}
+
+func TestDeadlineBudget(t *testing.T) {
+	var d Deadline
+	if _, ok := d.EncodeBudget(); ok {
+		t.Fatalf("EncodeBudget() of not set deadline returned true")
+	}
+	d.SetTimeout(time.Minute)
+	s, ok := d.EncodeBudget()
+	if !ok {
+		t.Fatalf("EncodeBudget() returned false")
+	}
+	r, err := DecodeBudget(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := r.Remaining(); n > time.Minute || n < time.Minute-time.Second {
+		t.Errorf("unexpected decoded remaining time: %s", n)
+	}
+}
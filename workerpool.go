@@ -0,0 +1,134 @@
+package deadline
+
+// OverflowPolicy defines how WorkerPool handles tasks when its queue is full.
+type OverflowPolicy uint8
+
+const (
+	// OverflowBlock makes the Goer to block until there is room in the queue
+	// or the task's cancelation channel becomes closed.
+	OverflowBlock OverflowPolicy = iota
+
+	// OverflowReject makes the Goer to drop the task immediately.
+	OverflowReject
+)
+
+// WorkerPool is a bounded pool of goroutines. Its Go method is a GoFunc and
+// so can be used as the Deadline's Goer. Its TryGo method is a RejectGoFunc
+// which makes Do() to return ErrRejected for dropped callbacks when used as
+// the Deadline's RejectGoer.
+//
+// Tasks which cancelation channel becomes closed while they are waiting in
+// the queue are dropped instead of being run.
+type WorkerPool struct {
+	// Overflow defines what to do with the task when the queue is full.
+	Overflow OverflowPolicy
+
+	// OnReject is an optional function called when a task is dropped due to
+	// the queue overflow or cancelation while waiting in the queue.
+	OnReject func()
+
+	sem  chan struct{}
+	work chan func()
+}
+
+// NewWorkerPool creates WorkerPool which runs at most workers goroutines and
+// queues at most queue tasks waiting for a free goroutine.
+func NewWorkerPool(workers, queue int) *WorkerPool {
+	if workers <= 0 {
+		panic("deadline: non-positive number of workers")
+	}
+	return &WorkerPool{
+		sem:  make(chan struct{}, workers),
+		work: make(chan func(), queue),
+	}
+}
+
+// Go schedules task to be run by the pool. It implements GoFunc.
+func (p *WorkerPool) Go(cancel <-chan struct{}, task func()) {
+	p.TryGo(cancel, task, nil)
+}
+
+// TryGo schedules task to be run by the pool. If task is dropped, reject is
+// called (if non-nil). It implements RejectGoFunc.
+func (p *WorkerPool) TryGo(cancel <-chan struct{}, task, reject func()) {
+	select {
+	case p.sem <- struct{}{}:
+		go p.worker(task)
+		return
+	default:
+	}
+	task = p.queued(cancel, task, reject)
+	if p.Overflow == OverflowReject {
+		select {
+		case p.work <- task:
+		default:
+			p.reject(reject)
+			return
+		}
+	} else {
+		select {
+		case p.work <- task:
+		case <-cancel:
+			p.reject(reject)
+			return
+		}
+	}
+	// All workers could exit after we failed to take the slot above but
+	// before the task was queued. Retry to not leave the queue without a
+	// worker.
+	select {
+	case p.sem <- struct{}{}:
+		go p.worker(nil)
+	default:
+	}
+}
+
+// queued wraps task to be dropped if cancel becomes closed while the task is
+// waiting in the queue.
+func (p *WorkerPool) queued(cancel <-chan struct{}, task, reject func()) func() {
+	return func() {
+		select {
+		case <-cancel:
+			p.reject(reject)
+		default:
+			task()
+		}
+	}
+}
+
+func (p *WorkerPool) reject(reject func()) {
+	if reject != nil {
+		reject()
+	}
+	if p.OnReject != nil {
+		p.OnReject()
+	}
+}
+
+// worker runs given task (if any) and then the queued ones until the queue
+// is empty. It must be started holding the p.sem slot.
+func (p *WorkerPool) worker(task func()) {
+	for {
+		if task != nil {
+			task()
+		}
+		select {
+		case task = <-p.work:
+			continue
+		default:
+		}
+		<-p.sem
+		// Task could be queued right before we released the slot. Recheck
+		// the queue to not leave it without a worker.
+		if len(p.work) == 0 {
+			return
+		}
+		select {
+		case p.sem <- struct{}{}:
+			task = nil
+		default:
+			// Another worker will pick it up.
+			return
+		}
+	}
+}
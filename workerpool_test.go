@@ -0,0 +1,92 @@
+package deadline
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWorkerPool(t *testing.T) {
+	var (
+		p        = NewWorkerPool(1, 1)
+		rejected int32
+		release  = make(chan struct{})
+	)
+	p.Overflow = OverflowReject
+	p.OnReject = func() {
+		atomic.AddInt32(&rejected, 1)
+	}
+	p.Go(nil, func() { <-release })
+
+	ran := make(chan struct{})
+	p.Go(nil, func() { close(ran) })      // Queued.
+	p.Go(nil, func() { t.Errorf("ran") }) // Rejected due to overflow.
+	if n := atomic.LoadInt32(&rejected); n != 1 {
+		t.Fatalf("unexpected rejected tasks: %d; want 1", n)
+	}
+	close(release)
+	select {
+	case <-ran:
+	case <-time.After(time.Second):
+		t.Fatalf("queued task was not run")
+	}
+}
+
+func TestWorkerPoolCanceledInQueue(t *testing.T) {
+	p := NewWorkerPool(1, 1)
+	release := make(chan struct{})
+	p.Go(nil, func() { <-release })
+
+	d := Deadline{Goer: p.Go}
+	d.Set(time.Now().Add(10 * time.Millisecond))
+	err := d.Do(func() {
+		t.Errorf("canceled task was run")
+	})
+	if err != ErrDeadline {
+		t.Errorf("unexpected error: %v; want %v", err, ErrDeadline)
+	}
+	close(release)
+	time.Sleep(10 * time.Millisecond)
+}
+
+func TestWorkerPoolTryGo(t *testing.T) {
+	p := NewWorkerPool(1, 0)
+	p.Overflow = OverflowReject
+	release := make(chan struct{})
+	defer close(release)
+	p.Go(nil, func() { <-release })
+
+	d := Deadline{RejectGoer: p.TryGo}
+	d.Set(time.Now().Add(time.Hour))
+	err := d.Do(func() {
+		t.Errorf("rejected task was run")
+	})
+	if err != ErrRejected || !errors.Is(err, ErrDeadline) {
+		t.Errorf("unexpected error: %v; want %v", err, ErrRejected)
+	}
+}
+
+func TestWorkerPoolNoLostTasks(t *testing.T) {
+	p := NewWorkerPool(2, 4)
+	var wg sync.WaitGroup
+	wg.Add(8 * 1000)
+	for i := 0; i < 8; i++ {
+		go func() {
+			for j := 0; j < 1000; j++ {
+				p.Go(nil, wg.Done)
+			}
+		}()
+	}
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("queued tasks were not run")
+	}
+}
@@ -0,0 +1,111 @@
+package deadline
+
+import (
+	"bufio"
+	"io"
+	"sync"
+	"time"
+)
+
+// Writer is a buffered writer which flushes buffered data automatically when
+// the associated deadline is about to expire. It helps not to lose buffered
+// bytes when the expired write deadline truncates the connection.
+//
+// Writer methods are safe for concurrent use.
+type Writer struct {
+	d      *Deadline
+	margin time.Duration
+
+	mu    sync.Mutex
+	bw    *bufio.Writer
+	timer Timer
+	armed bool
+	at    time.Time // Flush point the timer is armed for.
+}
+
+// NewWriter returns new Writer with default buffer size which flushes data
+// to w the margin before the deadline d expires.
+func NewWriter(w io.Writer, d *Deadline, margin time.Duration) *Writer {
+	return NewWriterSize(w, 0, d, margin)
+}
+
+// NewWriterSize is like NewWriter but with buffer of at least given size.
+func NewWriterSize(w io.Writer, size int, d *Deadline, margin time.Duration) *Writer {
+	return &Writer{
+		d:      d,
+		margin: margin,
+		bw:     bufio.NewWriterSize(w, size),
+	}
+}
+
+// Write writes p into the buffer.
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	n, err := w.bw.Write(p)
+	w.schedule()
+	return n, err
+}
+
+// Flush writes any buffered data to the underlying io.Writer.
+func (w *Writer) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	err := w.bw.Flush()
+	w.schedule()
+	return err
+}
+
+// Buffered returns the number of bytes that have been written into the
+// current buffer.
+func (w *Writer) Buffered() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.bw.Buffered()
+}
+
+// schedule arms or disarms the flush timer depending on the buffer state. It
+// must be called with w.mu held.
+func (w *Writer) schedule() {
+	if w.bw.Buffered() == 0 {
+		if w.armed {
+			w.timer.Stop()
+			w.armed = false
+		}
+		return
+	}
+	at := w.d.Expires()
+	if at.IsZero() {
+		return
+	}
+	at = at.Add(-w.margin)
+	if w.armed && !at.Before(w.at) {
+		// Timer re-arms itself if the deadline was moved further.
+		return
+	}
+	n := at.Sub(w.d.now())
+	if w.timer == nil {
+		w.timer = w.d.clock().AfterFunc(n, w.onTimer)
+	} else {
+		w.timer.Reset(n)
+	}
+	w.armed = true
+	w.at = at
+}
+
+func (w *Writer) onTimer() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.armed {
+		// Stale timer call after Stop() or Reset().
+		return
+	}
+	w.armed = false
+	at := w.d.Expires()
+	if !at.IsZero() && at.Add(-w.margin).After(w.d.now()) {
+		// Deadline was moved further since timer was armed.
+		w.schedule()
+		return
+	}
+	w.bw.Flush()
+}
@@ -0,0 +1,46 @@
+package deadline
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+	"time"
+)
+
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Len()
+}
+
+func TestWriterFlushBeforeDeadline(t *testing.T) {
+	var (
+		d   Deadline
+		dst syncBuffer
+	)
+	d.Set(time.Now().Add(50 * time.Millisecond))
+	w := NewWriter(&dst, &d, 20*time.Millisecond)
+	w.Write([]byte("hello"))
+	if dst.Len() != 0 {
+		t.Fatalf("data flushed too early")
+	}
+	select {
+	case <-d.Done():
+		t.Fatalf("deadline expired earlier than data was flushed")
+	case <-time.After(40 * time.Millisecond):
+	}
+	if n := dst.Len(); n != 5 {
+		t.Errorf("flushed %d bytes before deadline; want 5", n)
+	}
+}